@@ -0,0 +1,155 @@
+package pagemaster
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const paginationStageKey = "$pagemaster"
+
+// Aggregator is implemented by stores that can page through an aggregation pipeline
+type Aggregator interface {
+	Aggregate(ctx context.Context, pipeline mongo.Pipeline, q *Query) ([]bson.Raw, error)
+	AggregateCount(ctx context.Context, pipeline mongo.Pipeline, filter interface{}) (int64, error)
+}
+
+// PaginationStage returns a placeholder marking where the pagination stages are injected
+func PaginationStage() bson.D {
+	return bson.D{{Key: paginationStageKey, Value: bson.D{}}}
+}
+
+// AggregatePaginated executes an aggregation pipeline and returns a page of its output
+func (p *PageMaster) AggregatePaginated(pipeline mongo.Pipeline) ([]interface{}, error) {
+	docs, err := p.aggregate(p.ctx, pipeline)
+	if err != nil {
+		return make([]interface{}, 0), err
+	}
+
+	return decodeDocuments(docs)
+}
+
+// Aggregate executes an aggregation pipeline, decoding each document into a T
+func Aggregate[T any](p *PageMaster, pipeline mongo.Pipeline) ([]T, error) {
+	docs, err := p.aggregate(p.ctx, pipeline)
+	if err != nil {
+		return make([]T, 0), err
+	}
+
+	return decodeAll[T](docs)
+}
+
+func (p *PageMaster) aggregate(ctx context.Context, pipeline mongo.Pipeline) ([]bson.Raw, error) {
+	a, ok := p.Store().(Aggregator)
+	if !ok {
+		return nil, errors.New("pagemaster: store does not support aggregation pipelines")
+	}
+
+	query := func(ctx context.Context, q *Query) ([]bson.Raw, error) {
+		return a.Aggregate(ctx, pipeline, q)
+	}
+	count := func(ctx context.Context) (int64, error) {
+		return a.AggregateCount(ctx, pipeline, p.queryFilter())
+	}
+
+	return p.fetchPage(ctx, p.observeFind(QueryPage, query, pipeline), p.observeCount(count, pipeline))
+}
+
+// Aggregate executes a pipeline against the collection with the pagination stages injected
+func (s *MongoStore) Aggregate(ctx context.Context, pipeline mongo.Pipeline, q *Query) ([]bson.Raw, error) {
+	c, err := s.collection()
+	if err != nil {
+		return make([]bson.Raw, 0), err
+	}
+
+	cursor, err := c.Aggregate(ctx, injectStages(pipeline, paginationStages(q)), s.aggregateOptions())
+	if err != nil {
+		return make([]bson.Raw, 0), err
+	}
+
+	return readAll(ctx, cursor, q.Limit)
+}
+
+// AggregateCount counts the documents the pipeline produces for the filter
+func (s *MongoStore) AggregateCount(ctx context.Context, pipeline mongo.Pipeline, filter interface{}) (int64, error) {
+	c, err := s.collection()
+	if err != nil {
+		return 0, err
+	}
+
+	cursor, err := c.Aggregate(ctx, countPipeline(pipeline, filter), s.aggregateOptions())
+	if err != nil {
+		return 0, err
+	}
+
+	docs, err := readAll(ctx, cursor, 1)
+	if err != nil || len(docs) == 0 {
+		return 0, err
+	}
+
+	total, _ := rawInt(docs[0].Lookup("total"))
+	return total, nil
+}
+
+func (s *MongoStore) aggregateOptions() *options.AggregateOptions {
+	return &options.AggregateOptions{
+		Collation: s.Collation,
+		Hint:      s.Hint,
+		MaxTime:   s.maxTime(),
+	}
+}
+
+// countPipeline counts the documents the pipeline produces for the filter
+func countPipeline(pipeline mongo.Pipeline, filter interface{}) mongo.Pipeline {
+	stages := mongo.Pipeline{}
+	if !isEmptyFilter(filter) {
+		stages = append(stages, bson.D{{Key: "$match", Value: filter}})
+	}
+
+	return append(injectStages(pipeline, stages), bson.D{{Key: "$count", Value: "total"}})
+}
+
+// paginationStages translates a query into the aggregation stages that select its page
+func paginationStages(q *Query) mongo.Pipeline {
+	sort := normalizeSort(q.Sort)
+	stages := mongo.Pipeline{}
+
+	if f := combineFilters(q.Filter, keysetFilter(sort, q.After)); len(f) > 0 {
+		stages = append(stages, bson.D{{Key: "$match", Value: f}})
+	}
+
+	stages = append(stages, bson.D{{Key: "$sort", Value: sortDocument(sort)}})
+
+	if q.Skip > 0 {
+		stages = append(stages, bson.D{{Key: "$skip", Value: q.Skip}})
+	}
+
+	if q.Limit > 0 {
+		stages = append(stages, bson.D{{Key: "$limit", Value: q.Limit}})
+	}
+
+	if p := projectionDocument(q.Fields); p != nil {
+		stages = append(stages, bson.D{{Key: "$project", Value: p}})
+	}
+
+	return stages
+}
+
+// injectStages returns a copy of the pipeline with the stages at the pagination placeholder
+func injectStages(pipeline mongo.Pipeline, stages mongo.Pipeline) mongo.Pipeline {
+	out := make(mongo.Pipeline, 0, len(pipeline)+len(stages))
+
+	for i, s := range pipeline {
+		if len(s) == 1 && s[0].Key == paginationStageKey {
+			out = append(out, pipeline[:i]...)
+			out = append(out, stages...)
+			return append(out, pipeline[i+1:]...)
+		}
+	}
+
+	out = append(out, pipeline...)
+	return append(out, stages...)
+}
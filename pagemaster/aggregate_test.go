@@ -0,0 +1,188 @@
+package pagemaster
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// testAggregator runs queries against a MemoryStore and records their pipelines
+type testAggregator struct {
+	*MemoryStore
+	pipeline mongo.Pipeline
+}
+
+func (s *testAggregator) Aggregate(ctx context.Context, pipeline mongo.Pipeline, q *Query) ([]bson.Raw, error) {
+	s.pipeline = injectStages(pipeline, paginationStages(q))
+	return s.Find(ctx, q)
+}
+
+func (s *testAggregator) AggregateCount(ctx context.Context, pipeline mongo.Pipeline, filter interface{}) (int64, error) {
+	return s.Count(ctx, filter)
+}
+
+func Test_paginationStages(t *testing.T) {
+	tests := []struct {
+		name  string
+		query *Query
+		want  mongo.Pipeline
+	}{
+		0: {
+			name:  "it should sort and limit the first page",
+			query: &Query{Limit: 11},
+			want: mongo.Pipeline{
+				0: {{Key: "$sort", Value: bson.D{{Key: "_id", Value: -1}}}},
+				1: {{Key: "$limit", Value: int64(11)}},
+			},
+		},
+		1: {
+			name:  "it should match the cursor range and base filter",
+			query: &Query{After: []interface{}{"a"}, Filter: bson.M{"status": "active"}, Limit: 11},
+			want: mongo.Pipeline{
+				0: {{Key: "$match", Value: bson.M{"$and": bson.A{bson.M{"status": "active"}, bson.M{"_id": bson.M{"$lt": "a"}}}}}},
+				1: {{Key: "$sort", Value: bson.D{{Key: "_id", Value: -1}}}},
+				2: {{Key: "$limit", Value: int64(11)}},
+			},
+		},
+		2: {
+			name:  "it should skip and project after sorting",
+			query: &Query{Fields: []string{"name"}, Limit: 5, Skip: 10},
+			want: mongo.Pipeline{
+				0: {{Key: "$sort", Value: bson.D{{Key: "_id", Value: -1}}}},
+				1: {{Key: "$skip", Value: int64(10)}},
+				2: {{Key: "$limit", Value: int64(5)}},
+				3: {{Key: "$project", Value: bson.D{{Key: "name", Value: 1}}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := paginationStages(tt.query); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("paginationStages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_injectStages(t *testing.T) {
+	lookup := bson.D{{Key: "$lookup", Value: bson.D{{Key: "from", Value: "customers"}}}}
+	group := bson.D{{Key: "$group", Value: bson.D{{Key: "_id", Value: "$customer"}}}}
+	limit := bson.D{{Key: "$limit", Value: 10}}
+
+	tests := []struct {
+		name     string
+		pipeline mongo.Pipeline
+		want     mongo.Pipeline
+	}{
+		0: {
+			name:     "it should append the stages when there is no placeholder",
+			pipeline: mongo.Pipeline{0: group},
+			want:     mongo.Pipeline{0: group, 1: limit},
+		},
+		1: {
+			name:     "it should replace the placeholder with the stages",
+			pipeline: mongo.Pipeline{0: PaginationStage(), 1: lookup},
+			want:     mongo.Pipeline{0: limit, 1: lookup},
+		},
+		2: {
+			name:     "it should keep the stages around the placeholder in order",
+			pipeline: mongo.Pipeline{0: group, 1: PaginationStage(), 2: lookup},
+			want:     mongo.Pipeline{0: group, 1: limit, 2: lookup},
+		},
+		3: {
+			name:     "it should handle an empty pipeline",
+			pipeline: nil,
+			want:     mongo.Pipeline{0: limit},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := append(mongo.Pipeline{}, tt.pipeline...)
+			if got := injectStages(tt.pipeline, mongo.Pipeline{limit}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("injectStages() = %v, want %v", got, tt.want)
+			}
+			if len(tt.pipeline) > 0 && !reflect.DeepEqual(tt.pipeline, original) {
+				t.Errorf("injectStages() modified the pipeline = %v", tt.pipeline)
+			}
+		})
+	}
+}
+
+func TestPageMaster_AggregatePaginated(t *testing.T) {
+	testDocs := memoryTestDocuments(25)
+	memory, err := NewMemoryStore(testDocs)
+	if err != nil {
+		t.Errorf("NewMemoryStore() error = %v", err)
+		return
+	}
+	store := &testAggregator{MemoryStore: memory}
+	pipeline := mongo.Pipeline{0: PaginationStage(), 1: bson.D{{Key: "$lookup", Value: bson.D{{Key: "from", Value: "revisions"}}}}}
+
+	fetch := func(query string) (*PageMaster, []interface{}, mongo.Pipeline) {
+		r, _ := http.NewRequest("GET", "/things?pageSize=10"+query, nil)
+		p, err := New(&NewOptions{Request: r, Store: store})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		got, err := p.AggregatePaginated(pipeline)
+		if err != nil {
+			t.Fatalf("PageMaster.AggregatePaginated() error = %v", err)
+		}
+		return p, got, store.pipeline
+	}
+
+	first, firstPage, firstPipeline := fetch("&includeTotal=true")
+	second, _, secondPipeline := fetch("&from=" + first.NextToken())
+	back, backPage, _ := fetch("&before=" + second.PrevToken())
+	total, _ := first.Total()
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		0: {name: "the first page should be full", got: len(firstPage), want: 10},
+		1: {name: "the first page should have a next token", got: first.NextToken() != "", want: true},
+		2: {name: "the total should be counted through the aggregator", got: total, want: int64(25)},
+		3: {name: "the first pipeline should not match a cursor range", got: firstPipeline[0][0].Key, want: "$sort"},
+		4: {name: "the second pipeline should match the cursor range first", got: secondPipeline[0][0].Key, want: "$match"},
+		5: {name: "the caller's stages should follow the pagination stages", got: secondPipeline[len(secondPipeline)-1][0].Key, want: "$lookup"},
+		6: {name: "the second page should have a previous page", got: second.HasPrev(), want: true},
+		7: {name: "paging back should return the first page", got: backPage, want: firstPage},
+		8: {name: "paging back to the first page should not have a previous token", got: back.PrevToken(), want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageMaster_AggregatePaginated_UnsupportedStore(t *testing.T) {
+	store, err := NewMemoryStore(memoryTestDocuments(5))
+	if err != nil {
+		t.Errorf("NewMemoryStore() error = %v", err)
+		return
+	}
+
+	r, _ := http.NewRequest("GET", "/things", nil)
+	p, err := New(&NewOptions{Request: r, Store: store})
+	if err != nil {
+		t.Errorf("New() error = %v", err)
+		return
+	}
+
+	got, err := Aggregate[memoryTestDocument](p, mongo.Pipeline{})
+	if err == nil {
+		t.Errorf("Aggregate() error = %v, wantErr true", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("Aggregate() = %v, want empty", got)
+	}
+}
@@ -0,0 +1,131 @@
+package pagemaster
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const cursorVersion byte = 1
+
+// ErrInvalidCursor and ErrExpiredCursor are the causes of a CursorError
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrExpiredCursor = errors.New("expired cursor")
+)
+
+// DefaultCursorCodec is used by PageMasters that are not given a codec
+var DefaultCursorCodec *CursorCodec
+
+var now = time.Now
+
+// CursorError is returned when a cursor token cannot be decoded
+type CursorError struct {
+	Err    error
+	Reason string
+}
+
+func (e *CursorError) Error() string {
+	return e.Err.Error() + ": " + e.Reason
+}
+
+// Unwrap returns the underlying ErrInvalidCursor or ErrExpiredCursor
+func (e *CursorError) Unwrap() error {
+	return e.Err
+}
+
+// Cursor is the decoded payload of a cursor token
+type Cursor struct {
+	ExpiresAt int64          `bson:"e,omitempty"`
+	Search    string         `bson:"q,omitempty"`
+	Snapshot  *SnapshotBound `bson:"b,omitempty"`
+	Sort      []SortField    `bson:"s,omitempty"`
+	Values    []interface{}  `bson:"v"`
+}
+
+// CursorCodec encodes cursors into opaque, signed tokens
+type CursorCodec struct {
+	Keys [][]byte
+	TTL  time.Duration
+}
+
+// Encode signs the cursor and returns its token
+func (c *CursorCodec) Encode(cur *Cursor) (string, error) {
+	if len(c.Keys) == 0 {
+		return "", errors.New("cursor codec has no signing key")
+	}
+
+	enc := *cur
+	if c.TTL > 0 {
+		enc.ExpiresAt = now().Add(c.TTL).Unix()
+	}
+
+	b, err := bson.Marshal(enc)
+	if err != nil {
+		return "", err
+	}
+
+	payload := append([]byte{cursorVersion}, b...)
+	sig := sign(c.Keys[0], payload)
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// Decode verifies a token against the codec's keys and returns its cursor
+func (c *CursorCodec) Decode(token string) (*Cursor, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, &CursorError{Err: ErrInvalidCursor, Reason: "malformed token"}
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || len(payload) < 2 {
+		return nil, &CursorError{Err: ErrInvalidCursor, Reason: "malformed payload"}
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, &CursorError{Err: ErrInvalidCursor, Reason: "malformed signature"}
+	}
+
+	if !c.verify(payload, sig) {
+		return nil, &CursorError{Err: ErrInvalidCursor, Reason: "signature mismatch"}
+	}
+
+	if payload[0] != cursorVersion {
+		return nil, &CursorError{Err: ErrInvalidCursor, Reason: "unsupported version"}
+	}
+
+	var cur Cursor
+	err = bson.Unmarshal(payload[1:], &cur)
+	if err != nil {
+		return nil, &CursorError{Err: ErrInvalidCursor, Reason: "malformed payload"}
+	}
+
+	if cur.ExpiresAt != 0 && now().Unix() > cur.ExpiresAt {
+		return nil, &CursorError{Err: ErrExpiredCursor, Reason: "token has expired"}
+	}
+
+	return &cur, nil
+}
+
+func (c *CursorCodec) verify(payload, sig []byte) bool {
+	for _, k := range c.Keys {
+		if hmac.Equal(sign(k, payload), sig) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sign(key, payload []byte) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write(payload)
+	return m.Sum(nil)
+}
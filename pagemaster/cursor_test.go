@@ -0,0 +1,159 @@
+package pagemaster
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMain(m *testing.M) {
+	DefaultCursorCodec = &CursorCodec{Keys: [][]byte{[]byte("pagemaster-test")}}
+	os.Exit(m.Run())
+}
+
+func TestCursorCodec_Decode(t *testing.T) {
+	testID := primitive.NewObjectID()
+	oldKey := []byte("old-key")
+	newKey := []byte("new-key")
+
+	oldCodec := &CursorCodec{Keys: [][]byte{oldKey}}
+	rotatedCodec := &CursorCodec{Keys: [][]byte{newKey, oldKey}}
+	expiringCodec := &CursorCodec{Keys: [][]byte{newKey}, TTL: time.Minute}
+
+	oldToken, _ := oldCodec.Encode(&Cursor{Values: []interface{}{testID}})
+	newToken, _ := rotatedCodec.Encode(&Cursor{Values: []interface{}{testID}})
+	expiringToken, _ := expiringCodec.Encode(&Cursor{Values: []interface{}{testID}})
+	parts := strings.Split(newToken, ".")
+	tampered := []byte(parts[0])
+	tampered[4] ^= 1
+
+	tests := []struct {
+		name    string
+		codec   *CursorCodec
+		token   string
+		now     time.Time
+		want    []interface{}
+		wantErr error
+	}{
+		0: {
+			name:  "it should round trip a token",
+			codec: rotatedCodec,
+			token: newToken,
+			want:  []interface{}{testID},
+		},
+		1: {
+			name:  "it should accept tokens signed by a rotated key",
+			codec: rotatedCodec,
+			token: oldToken,
+			want:  []interface{}{testID},
+		},
+		2: {
+			name:    "it should reject tokens signed by an unknown key",
+			codec:   oldCodec,
+			token:   newToken,
+			wantErr: ErrInvalidCursor,
+		},
+		3: {
+			name:    "it should reject a tampered payload",
+			codec:   rotatedCodec,
+			token:   string(tampered) + "." + parts[1],
+			wantErr: ErrInvalidCursor,
+		},
+		4: {
+			name:    "it should reject a raw object id",
+			codec:   rotatedCodec,
+			token:   testID.Hex(),
+			wantErr: ErrInvalidCursor,
+		},
+		5: {
+			name:  "it should accept a token before it expires",
+			codec: expiringCodec,
+			token: expiringToken,
+			now:   time.Now().Add(30 * time.Second),
+			want:  []interface{}{testID},
+		},
+		6: {
+			name:    "it should reject a token after it expires",
+			codec:   expiringCodec,
+			token:   expiringToken,
+			now:     time.Now().Add(2 * time.Minute),
+			wantErr: ErrExpiredCursor,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !tt.now.IsZero() {
+				now = func() time.Time { return tt.now }
+				defer func() { now = time.Now }()
+			}
+
+			got, err := tt.codec.Decode(tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CursorCodec.Decode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			var ce *CursorError
+			if tt.wantErr != nil && !errors.As(err, &ce) {
+				t.Errorf("CursorCodec.Decode() error is not a *CursorError = %T", err)
+				return
+			}
+
+			if tt.wantErr == nil && !reflect.DeepEqual(got.Values, tt.want) {
+				t.Errorf("CursorCodec.Decode() = %v, want %v", got.Values, tt.want)
+			}
+		})
+	}
+}
+
+func TestCursorCodec_RoundTrip(t *testing.T) {
+	codec := &CursorCodec{Keys: [][]byte{[]byte("key")}}
+	testID := primitive.NewObjectID()
+	testUUID := primitive.Binary{Subtype: 0x04, Data: []byte("0123456789abcdef")}
+	testTime := time.Date(2020, 1, 2, 3, 4, 5, 6000000, time.UTC)
+
+	tests := []struct {
+		name string
+		key  interface{}
+		want interface{}
+	}{
+		0: {name: "it should preserve a string key", key: "order-0001", want: "order-0001"},
+		1: {name: "it should preserve an int32 key", key: int32(42), want: int32(42)},
+		2: {name: "it should preserve an int64 key", key: int64(1) << 40, want: int64(1) << 40},
+		3: {name: "it should preserve an object id key", key: testID, want: testID},
+		4: {name: "it should preserve a uuid key", key: testUUID, want: testUUID},
+		5: {name: "it should preserve a time key", key: testTime, want: primitive.NewDateTimeFromTime(testTime)},
+		6: {name: "it should preserve a float key", key: 1.5, want: 1.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := bson.Marshal(bson.D{{Key: "_id", Value: tt.key}})
+			if err != nil {
+				t.Errorf("bson.Marshal() error = %v", err)
+				return
+			}
+
+			token, err := codec.Encode(&Cursor{Values: sortValues(doc, defaultSort)})
+			if err != nil {
+				t.Errorf("CursorCodec.Encode() error = %v", err)
+				return
+			}
+
+			got, err := codec.Decode(token)
+			if err != nil {
+				t.Errorf("CursorCodec.Decode() error = %v", err)
+				return
+			}
+
+			if !reflect.DeepEqual(got.Values, []interface{}{tt.want}) {
+				t.Errorf("CursorCodec.Decode() = %#v, want %#v", got.Values, []interface{}{tt.want})
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package pagemaster
+
+import (
+	"errors"
+
+	apierrors "github.com/joeyfromspace/go-api-errors/v2/errors"
+)
+
+// Errors returned by New when a required option is missing
+var (
+	ErrNoRequest     = errors.New("pagemaster: instantiated with no request")
+	ErrNoDatabase    = errors.New("pagemaster: instantiated with no database")
+	ErrNoCollection  = errors.New("pagemaster: instantiated with no collection name")
+	ErrNoCursorCodec = errors.New("pagemaster: instantiated with no cursor codec key")
+)
+
+// ErrInvalidMode is returned by New when the Mode is unknown
+var ErrInvalidMode = errors.New("pagemaster: instantiated with an unknown mode")
+
+// ErrSnapshotMode is returned by New when Snapshot is set outside of keyset mode
+var ErrSnapshotMode = errors.New("pagemaster: snapshots are only supported in keyset mode")
+
+// ErrSnapshotKey is returned when a snapshot is pinned on an _id that is not increasing
+var ErrSnapshotKey = errors.New("pagemaster: snapshot needs a SnapshotField when _id is not an ObjectID or number")
+
+// ErrStoreOptions is returned by New when mongodb query options are passed with a Store
+var ErrStoreOptions = errors.New("pagemaster: set Collation, Hint, MaxTime and ReadPreference on the MongoStore instead of NewOptions")
+
+// RequestError is returned when the pagination parameters of a request are invalid
+type RequestError struct {
+	Parameter string
+	Detail    string
+	Err       error
+}
+
+func (e *RequestError) Error() string {
+	return "pagemaster: invalid " + e.Parameter + " parameter: " + e.Detail
+}
+
+// Unwrap returns the underlying error, such as a *CursorError
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// APIError converts the error into a 400 response that can be sent with go-api-errors
+func (e *RequestError) APIError() *apierrors.APIError {
+	return &apierrors.APIError{
+		Name:       "Bad Request",
+		AppCode:    "0",
+		StatusCode: 400,
+		Detail:     e.Detail,
+		Pointer:    e.Parameter,
+	}
+}
@@ -0,0 +1,185 @@
+package pagemaster
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FieldType is the type a filter value is parsed into before it is used in a query
+type FieldType int
+
+// Supported filter value types
+const (
+	FilterString FieldType = iota
+	FilterInt
+	FilterFloat
+	FilterBool
+	FilterTime
+	FilterObjectID
+)
+
+func (t FieldType) String() string {
+	switch t {
+	case FilterString:
+		return "string"
+	case FilterInt:
+		return "int"
+	case FilterFloat:
+		return "float"
+	case FilterBool:
+		return "bool"
+	case FilterTime:
+		return "time"
+	case FilterObjectID:
+		return "ObjectID"
+	default:
+		return "FieldType(" + strconv.Itoa(int(t)) + ")"
+	}
+}
+
+var filterOperators = map[string]string{
+	"eq":  "$eq",
+	"ne":  "$ne",
+	"gt":  "$gt",
+	"gte": "$gte",
+	"lt":  "$lt",
+	"lte": "$lte",
+	"in":  "$in",
+	"nin": "$nin",
+}
+
+// FilterField declares a field clients may filter on
+type FilterField struct {
+	Key       string
+	Operators []string
+	Type      FieldType
+}
+
+// FilterSchema declares the filterable fields of a resource
+type FilterSchema map[string]FilterField
+
+// ParseFilter translates filter query parameters into a mongodb filter
+func ParseFilter(values url.Values, schema FilterSchema) (bson.D, error) {
+	params := make([]string, 0)
+	for k := range values {
+		if strings.HasPrefix(k, "filter[") {
+			params = append(params, k)
+		}
+	}
+	sort.Strings(params)
+
+	conditions := map[string]bson.D{}
+	keys := make([]string, 0)
+
+	for _, param := range params {
+		name, op, ok := parseFilterParam(param)
+		if !ok {
+			return nil, &RequestError{Parameter: param, Detail: "malformed filter parameter"}
+		}
+
+		field, ok := schema[name]
+		if !ok {
+			return nil, &RequestError{Parameter: param, Detail: "cannot filter by " + name}
+		}
+
+		allowed := field.Operators
+		if len(allowed) == 0 {
+			allowed = []string{"eq"}
+		}
+
+		mop, ok := filterOperators[op]
+		if !ok || !contains(allowed, op) {
+			return nil, &RequestError{Parameter: param, Detail: "cannot filter " + name + " with " + op}
+		}
+
+		if len(values[param]) != 1 {
+			return nil, &RequestError{Parameter: param, Detail: "filter parameters cannot be repeated"}
+		}
+
+		v, err := parseFilterValue(values[param][0], op, field.Type)
+		if err != nil {
+			return nil, &RequestError{Parameter: param, Detail: "expected " + field.Type.String() + " value", Err: err}
+		}
+
+		key := field.Key
+		if key == "" {
+			key = name
+		}
+
+		if _, ok := conditions[key]; !ok {
+			keys = append(keys, key)
+		}
+		conditions[key] = append(conditions[key], bson.E{Key: mop, Value: v})
+	}
+
+	f := bson.D{}
+	for _, k := range keys {
+		c := conditions[k]
+		if len(c) == 1 && c[0].Key == "$eq" {
+			f = append(f, bson.E{Key: k, Value: c[0].Value})
+			continue
+		}
+		f = append(f, bson.E{Key: k, Value: c})
+	}
+
+	return f, nil
+}
+
+// parseFilterParam splits filter[name] and filter[name][op] into the field name and operator
+func parseFilterParam(p string) (string, string, bool) {
+	parts := strings.Split(strings.TrimPrefix(p, "filter["), "][")
+	last := len(parts) - 1
+	if !strings.HasSuffix(parts[last], "]") {
+		return "", "", false
+	}
+	parts[last] = strings.TrimSuffix(parts[last], "]")
+
+	switch {
+	case len(parts) == 1 && parts[0] != "":
+		return parts[0], "eq", true
+	case len(parts) == 2 && parts[0] != "" && parts[1] != "":
+		return parts[0], parts[1], true
+	}
+
+	return "", "", false
+}
+
+func parseFilterValue(s string, op string, t FieldType) (interface{}, error) {
+	if op != "in" && op != "nin" {
+		return parseFilterScalar(s, t)
+	}
+
+	a := bson.A{}
+	for _, e := range strings.Split(s, ",") {
+		v, err := parseFilterScalar(e, t)
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, v)
+	}
+
+	return a, nil
+}
+
+func parseFilterScalar(s string, t FieldType) (interface{}, error) {
+	switch t {
+	case FilterInt:
+		return strconv.ParseInt(s, 10, 64)
+	case FilterFloat:
+		return strconv.ParseFloat(s, 64)
+	case FilterBool:
+		return strconv.ParseBool(s)
+	case FilterTime:
+		return time.Parse(time.RFC3339, s)
+	case FilterObjectID:
+		return primitive.ObjectIDFromHex(s)
+	}
+
+	return s, nil
+}
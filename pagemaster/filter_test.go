@@ -0,0 +1,156 @@
+package pagemaster
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseFilter(t *testing.T) {
+	testID := primitive.NewObjectID()
+	testTime := time.Date(2020, 8, 1, 12, 0, 0, 0, time.UTC)
+	schema := FilterSchema{
+		"status":   {Type: FilterString},
+		"price":    {Type: FilterFloat, Operators: []string{"gte", "lt"}},
+		"tags":     {Type: FilterString, Operators: []string{"eq", "in"}},
+		"qty":      {Type: FilterInt, Operators: []string{"eq", "ne"}},
+		"archived": {Type: FilterBool},
+		"since":    {Type: FilterTime, Key: "createdAt", Operators: []string{"gt"}},
+		"owner":    {Type: FilterObjectID, Key: "ownerId"},
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		want      bson.D
+		wantParam string
+	}{
+		0: {
+			name:  "it should return an empty filter without parameters",
+			query: "pageSize=10",
+			want:  bson.D{},
+		},
+		1: {
+			name:  "it should translate equality, ranges and lists",
+			query: "filter[status]=active&filter[price][gte]=10&filter[price][lt]=20.5&filter[tags][in]=a,b",
+			want: bson.D{
+				{Key: "price", Value: bson.D{{Key: "$gte", Value: 10.0}, {Key: "$lt", Value: 20.5}}},
+				{Key: "status", Value: "active"},
+				{Key: "tags", Value: bson.D{{Key: "$in", Value: bson.A{"a", "b"}}}},
+			},
+		},
+		2: {
+			name:  "it should parse typed values and map keys",
+			query: "filter[qty][ne]=3&filter[archived]=false&filter[since][gt]=2020-08-01T12:00:00Z&filter[owner]=" + testID.Hex(),
+			want: bson.D{
+				{Key: "archived", Value: false},
+				{Key: "ownerId", Value: testID},
+				{Key: "qty", Value: bson.D{{Key: "$ne", Value: int64(3)}}},
+				{Key: "createdAt", Value: bson.D{{Key: "$gt", Value: testTime}}},
+			},
+		},
+		3: {
+			name:      "it should reject unknown fields",
+			query:     "filter[secret]=1",
+			wantParam: "filter[secret]",
+		},
+		4: {
+			name:      "it should reject operators that are not allowed",
+			query:     "filter[status][ne]=active",
+			wantParam: "filter[status][ne]",
+		},
+		5: {
+			name:      "it should reject values of the wrong type",
+			query:     "filter[qty]=many",
+			wantParam: "filter[qty]",
+		},
+		6: {
+			name:      "it should reject malformed parameters",
+			query:     "filter[price][gte",
+			wantParam: "filter[price][gte",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			got, err := ParseFilter(values, schema)
+
+			if tt.wantParam != "" {
+				var re *RequestError
+				if !errors.As(err, &re) || re.Parameter != tt.wantParam {
+					t.Errorf("ParseFilter() error = %v, want a *RequestError for %v", err, tt.wantParam)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("ParseFilter() error = %v", err)
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageMaster_FindPaginated_FilterSchema(t *testing.T) {
+	store, err := NewMemoryStore(memoryTestDocuments(30))
+	if err != nil {
+		t.Errorf("NewMemoryStore() error = %v", err)
+		return
+	}
+
+	r, _ := http.NewRequest("GET", "/things?filter[rev][gte]=5&filter[rev][lt]=8", nil)
+	p, err := New(&NewOptions{
+		FilterSchema: FilterSchema{"rev": {Type: FilterInt, Operators: []string{"gte", "lt"}}},
+		Request:      r,
+		Store:        store,
+	})
+	if err != nil {
+		t.Errorf("New() error = %v", err)
+		return
+	}
+
+	got, err := p.FindPaginated()
+	if err != nil {
+		t.Errorf("PageMaster.FindPaginated() error = %v", err)
+		return
+	}
+
+	revs := make([]interface{}, 0)
+	for _, d := range got {
+		revs = append(revs, d.(bson.D)[2].Value)
+	}
+
+	if want := []interface{}{int32(7), int32(6), int32(5)}; !reflect.DeepEqual(revs, want) {
+		t.Errorf("PageMaster.FindPaginated() = %v, want %v", revs, want)
+	}
+}
+
+func TestFieldType_String(t *testing.T) {
+	tests := []struct {
+		name string
+		t    FieldType
+		want string
+	}{
+		0: {name: "it should name a string", t: FilterString, want: "string"},
+		1: {name: "it should name an object id", t: FilterObjectID, want: "ObjectID"},
+		2: {name: "it should not panic on an unknown type", t: FieldType(6), want: "FieldType(6)"},
+		3: {name: "it should not panic on a negative type", t: FieldType(-1), want: "FieldType(-1)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.t.String(); got != tt.want {
+				t.Errorf("FieldType.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
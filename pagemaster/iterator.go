@@ -0,0 +1,170 @@
+package pagemaster
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// IteratorOptions used to specify options for a new Iterator
+type IteratorOptions struct {
+	Context  context.Context
+	Prefetch bool
+}
+
+// Iterator walks every document of a PageMaster query, fetching pages as needed
+type Iterator struct {
+	cancel   context.CancelFunc
+	closed   bool
+	ctx      context.Context
+	current  bson.Raw
+	docs     []bson.Raw
+	err      error
+	last     bool
+	p        *PageMaster
+	pending  chan iteratorPage
+	pos      int
+	prefetch bool
+}
+
+type iteratorPage struct {
+	docs []bson.Raw
+	err  error
+	last bool
+}
+
+// Iterate returns an Iterator that walks forward from the PageMaster's current page
+func (p *PageMaster) Iterate(o *IteratorOptions) *Iterator {
+	if o == nil {
+		o = &IteratorOptions{}
+	}
+
+	ctx := o.Context
+	if ctx == nil {
+		ctx = p.ctx
+	}
+
+	it := &Iterator{p: p, prefetch: o.Prefetch}
+	it.ctx, it.cancel = context.WithCancel(ctx)
+
+	return it
+}
+
+// Next advances to the next document, fetching another page when needed
+func (it *Iterator) Next() bool {
+	if it.closed || it.err != nil {
+		return false
+	}
+
+	for it.pos >= len(it.docs) {
+		if it.last {
+			it.current = nil
+			return false
+		}
+
+		page := it.nextPage()
+		if page.err != nil {
+			it.err = page.err
+			it.current = nil
+			return false
+		}
+
+		it.docs, it.pos, it.last = page.docs, 0, page.last
+	}
+
+	it.current = it.docs[it.pos]
+	it.pos++
+
+	return true
+}
+
+// Decode unmarshals the current document into v
+func (it *Iterator) Decode(v interface{}) error {
+	if it.current == nil {
+		return errors.New("pagemaster: Decode called without a current document")
+	}
+
+	return bson.Unmarshal(it.current, v)
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// Close stops the iteration, cancelling any page being prefetched
+func (it *Iterator) Close() error {
+	if it.closed {
+		return nil
+	}
+
+	it.closed = true
+	it.cancel()
+
+	if it.pending != nil {
+		<-it.pending
+		it.pending = nil
+	}
+
+	it.current = nil
+	it.docs = nil
+
+	return nil
+}
+
+// nextPage returns the prefetched page when there is one and starts prefetching the page after it
+func (it *Iterator) nextPage() iteratorPage {
+	var page iteratorPage
+	if it.pending != nil {
+		page = <-it.pending
+		it.pending = nil
+	} else {
+		page = it.load()
+	}
+
+	if it.prefetch && page.err == nil && !page.last {
+		it.pending = make(chan iteratorPage, 1)
+		go func(c chan iteratorPage) {
+			c <- it.load()
+		}(it.pending)
+	}
+
+	return page
+}
+
+// load fetches the PageMaster's current page and moves it on to the following one
+func (it *Iterator) load() iteratorPage {
+	if err := it.ctx.Err(); err != nil {
+		return iteratorPage{err: err}
+	}
+
+	if it.p.mode == OffsetMode && !withinMaxOffset(it.p.page, it.p.pageSize, it.p.maxOffset) {
+		return iteratorPage{err: errPageBeyondMaxOffset()}
+	}
+
+	docs, err := it.p.find(it.ctx)
+	if err != nil {
+		return iteratorPage{err: err}
+	}
+
+	more, err := it.p.advance()
+	return iteratorPage{docs: docs, err: err, last: !more}
+}
+
+// advance moves the PageMaster to the next page, reporting whether there is one
+func (p *PageMaster) advance() (bool, error) {
+	p.includeTotal = false
+
+	if p.mode == OffsetMode {
+		p.page++
+		return p.hasNext, nil
+	}
+
+	if p.nextToken == "" {
+		return false, nil
+	}
+
+	p.backward = false
+	return true, p.applyCursor("from", p.nextToken, false)
+}
@@ -0,0 +1,255 @@
+package pagemaster
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// testFailingStore serves pages from a MemoryStore until it has been queried failAfter times
+type testFailingStore struct {
+	*MemoryStore
+	calls     int
+	failAfter int
+}
+
+var errTestStore = errors.New("store failed")
+
+func (s *testFailingStore) Find(ctx context.Context, q *Query) ([]bson.Raw, error) {
+	s.calls++
+	if s.calls > s.failAfter {
+		return nil, errTestStore
+	}
+	return s.MemoryStore.Find(ctx, q)
+}
+
+func TestIterator(t *testing.T) {
+	testDocs := memoryTestDocuments(25)
+	memory, err := NewMemoryStore(testDocs)
+	if err != nil {
+		t.Errorf("NewMemoryStore() error = %v", err)
+		return
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	all := make([]int, 0)
+	for i := 24; i >= 0; i-- {
+		all = append(all, i)
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		from    bool
+		mode    Mode
+		store   Store
+		options *IteratorOptions
+		stopAt  int
+		want    []int
+		wantErr error
+	}{
+		0: {
+			name:  "it should walk every page",
+			query: "pageSize=10",
+			store: memory,
+			want:  all,
+		},
+		1: {
+			name:    "it should walk every page while prefetching",
+			query:   "pageSize=10",
+			store:   memory,
+			options: &IteratorOptions{Prefetch: true},
+			want:    all,
+		},
+		2: {
+			name:  "it should walk a query that fits on one page",
+			query: "pageSize=50",
+			store: memory,
+			want:  all,
+		},
+		3: {
+			name:  "it should walk every page in offset mode",
+			query: "pageSize=10",
+			mode:  OffsetMode,
+			store: memory,
+			want:  all,
+		},
+		4: {
+			name:    "it should start from the page's position",
+			query:   "pageSize=10",
+			from:    true,
+			store:   memory,
+			options: &IteratorOptions{Prefetch: true},
+			want:    all[10:],
+		},
+		5: {
+			name:    "it should stop when the context is cancelled",
+			query:   "pageSize=10",
+			store:   memory,
+			options: &IteratorOptions{Context: cancelled},
+			want:    []int{},
+			wantErr: context.Canceled,
+		},
+		6: {
+			name:    "it should return the documents fetched before a store error",
+			query:   "pageSize=10",
+			store:   &testFailingStore{MemoryStore: memory, failAfter: 2},
+			options: &IteratorOptions{Prefetch: true},
+			want:    all[:20],
+			wantErr: errTestStore,
+		},
+		7: {
+			name:    "it should stop when closed",
+			query:   "pageSize=10",
+			store:   memory,
+			options: &IteratorOptions{Prefetch: true},
+			stopAt:  15,
+			want:    all[:15],
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := tt.query
+			if tt.from {
+				query += "&from=" + mustFindFirstPage(t, memory).NextToken()
+			}
+
+			r, _ := http.NewRequest("GET", "/things?"+query, nil)
+			p, err := New(&NewOptions{Request: r, Mode: tt.mode, Store: tt.store})
+			if err != nil {
+				t.Errorf("New() error = %v", err)
+				return
+			}
+
+			it := p.Iterate(tt.options)
+			defer it.Close()
+
+			got := make([]int, 0)
+			for it.Next() {
+				var d memoryTestDocument
+				if err := it.Decode(&d); err != nil {
+					t.Errorf("Iterator.Decode() error = %v", err)
+					return
+				}
+				got = append(got, d.Rev)
+
+				if tt.stopAt > 0 && len(got) == tt.stopAt {
+					it.Close()
+				}
+			}
+
+			if !errors.Is(it.Err(), tt.wantErr) {
+				t.Errorf("Iterator.Err() = %v, want %v", it.Err(), tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Iterator revs = %v, want %v", got, tt.want)
+			}
+			if err := it.Decode(&memoryTestDocument{}); err == nil {
+				t.Errorf("Iterator.Decode() after the last document error = %v, wantErr true", err)
+			}
+		})
+	}
+}
+
+func mustFindFirstPage(t *testing.T, store Store) *PageMaster {
+	r, _ := http.NewRequest("GET", "/things?pageSize=10", nil)
+	p, err := New(&NewOptions{Request: r, Store: store})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := p.FindPaginated(); err != nil {
+		t.Fatalf("PageMaster.FindPaginated() error = %v", err)
+	}
+	return p
+}
+
+// testCountingStore counts the count queries run against a MemoryStore
+type testCountingStore struct {
+	*MemoryStore
+	counts int
+}
+
+func (s *testCountingStore) Count(ctx context.Context, filter interface{}) (int64, error) {
+	s.counts++
+	return s.MemoryStore.Count(ctx, filter)
+}
+
+func (s *testCountingStore) EstimatedCount(ctx context.Context) (int64, error) {
+	s.counts++
+	return s.MemoryStore.EstimatedCount(ctx)
+}
+
+func TestIterator_OffsetMode(t *testing.T) {
+	memory, err := NewMemoryStore(memoryTestDocuments(45))
+	if err != nil {
+		t.Errorf("NewMemoryStore() error = %v", err)
+		return
+	}
+
+	tests := []struct {
+		name       string
+		maxOffset  int64
+		wantCount  int
+		wantCounts int
+		wantParam  string
+	}{
+		0: {name: "it should count the total once", maxOffset: 100, wantCount: 45, wantCounts: 1},
+		1: {name: "it should stop with an error past the maximum offset", maxOffset: 20, wantCount: 30, wantCounts: 1, wantParam: "page"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &testCountingStore{MemoryStore: memory}
+			r, _ := http.NewRequest("GET", "/things?pageSize=10", nil)
+			p, err := New(&NewOptions{MaxOffset: tt.maxOffset, Mode: OffsetMode, Request: r, Store: store})
+			if err != nil {
+				t.Errorf("New() error = %v", err)
+				return
+			}
+
+			it := p.Iterate(nil)
+			defer it.Close()
+
+			n := 0
+			for it.Next() {
+				n++
+			}
+
+			var re *RequestError
+			if errors.As(it.Err(), &re) != (tt.wantParam != "") || re != nil && re.Parameter != tt.wantParam {
+				t.Errorf("Iterator.Err() = %v, wantParam %v", it.Err(), tt.wantParam)
+			}
+			if n != tt.wantCount || store.counts != tt.wantCounts {
+				t.Errorf("Iterator documents, counts = %v, %v, want %v, %v", n, store.counts, tt.wantCount, tt.wantCounts)
+			}
+			if total, ok := p.Total(); !ok || total != 45 {
+				t.Errorf("PageMaster.Total() = %v, %v, want 45, true", total, ok)
+			}
+		})
+	}
+}
+
+func TestIterator_Close(t *testing.T) {
+	p := mustFindFirstPage(t, mustMemoryStore(t, 25))
+
+	it := p.Iterate(&IteratorOptions{Prefetch: true})
+	it.Next()
+	it.Close()
+
+	if _, err := p.FindPaginated(); err != nil {
+		t.Errorf("PageMaster.FindPaginated() after Iterator.Close() error = %v", err)
+	}
+}
+
+func mustMemoryStore(t *testing.T, n int) *MemoryStore {
+	s, err := NewMemoryStore(memoryTestDocuments(n))
+	if err != nil {
+		t.Fatalf("NewMemoryStore() error = %v", err)
+	}
+	return s
+}
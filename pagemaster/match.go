@@ -0,0 +1,233 @@
+package pagemaster
+
+import (
+	"errors"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// combineFilters joins non-empty filters with $and
+func combineFilters(filters ...interface{}) bson.M {
+	nonEmpty := make(bson.A, 0, len(filters))
+	for _, f := range filters {
+		if !isEmptyFilter(f) {
+			nonEmpty = append(nonEmpty, f)
+		}
+	}
+
+	switch len(nonEmpty) {
+	case 0:
+		return bson.M{}
+	case 1:
+		if m, ok := nonEmpty[0].(bson.M); ok {
+			return m
+		}
+	}
+
+	return bson.M{"$and": nonEmpty}
+}
+
+func isEmptyFilter(f interface{}) bool {
+	switch v := f.(type) {
+	case nil:
+		return true
+	case bson.M:
+		return len(v) == 0
+	case bson.D:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+
+	return false
+}
+
+// matchFilter evaluates a mongodb query filter against a document
+func matchFilter(doc bson.Raw, filter interface{}) (bool, error) {
+	if isEmptyFilter(filter) {
+		return true, nil
+	}
+
+	b, err := bson.Marshal(filter)
+	if err != nil {
+		return false, err
+	}
+
+	return matchDocument(doc, bson.Raw(b))
+}
+
+func matchDocument(doc, filter bson.Raw) (bool, error) {
+	elems, err := filter.Elements()
+	if err != nil {
+		return false, err
+	}
+
+	for _, e := range elems {
+		var ok bool
+
+		switch e.Key() {
+		case "$and", "$or", "$nor":
+			ok, err = matchLogical(doc, e.Key(), e.Value())
+		default:
+			ok, err = matchField(doc, e.Key(), e.Value())
+		}
+
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func matchLogical(doc bson.Raw, op string, v bson.RawValue) (bool, error) {
+	arr, ok := v.ArrayOK()
+	if !ok {
+		return false, errors.New("pagemaster: " + op + " requires an array")
+	}
+
+	clauses, err := arr.Values()
+	if err != nil {
+		return false, err
+	}
+
+	for _, c := range clauses {
+		sub, ok := c.DocumentOK()
+		if !ok {
+			return false, errors.New("pagemaster: " + op + " requires an array of documents")
+		}
+
+		m, err := matchDocument(doc, sub)
+		if err != nil {
+			return false, err
+		}
+
+		switch {
+		case op == "$and" && !m:
+			return false, nil
+		case op == "$or" && m:
+			return true, nil
+		case op == "$nor" && m:
+			return false, nil
+		}
+	}
+
+	return op != "$or", nil
+}
+
+func matchField(doc bson.Raw, key string, cond bson.RawValue) (bool, error) {
+	field := lookupField(doc, key)
+
+	ops, ok := cond.DocumentOK()
+	if !ok || !isOperatorDocument(ops) {
+		return matchEq(field, cond), nil
+	}
+
+	elems, err := ops.Elements()
+	if err != nil {
+		return false, err
+	}
+
+	for _, e := range elems {
+		m, err := matchOperator(field, e.Key(), e.Value())
+		if err != nil || !m {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func isOperatorDocument(d bson.Raw) bool {
+	elems, err := d.Elements()
+	return err == nil && len(elems) > 0 && strings.HasPrefix(elems[0].Key(), "$")
+}
+
+func matchOperator(field bson.RawValue, op string, v bson.RawValue) (bool, error) {
+	switch op {
+	case "$eq":
+		return matchEq(field, v), nil
+	case "$ne":
+		return !matchEq(field, v), nil
+	case "$gt", "$gte", "$lt", "$lte":
+		return matchCompare(field, op, v), nil
+	case "$in", "$nin":
+		arr, ok := v.ArrayOK()
+		if !ok {
+			return false, errors.New("pagemaster: " + op + " requires an array")
+		}
+		values, err := arr.Values()
+		if err != nil {
+			return false, err
+		}
+		in := false
+		for _, iv := range values {
+			if matchEq(field, iv) {
+				in = true
+				break
+			}
+		}
+		return in == (op == "$in"), nil
+	case "$exists":
+		return (field.Type != 0) == truthy(v), nil
+	}
+
+	return false, errors.New("pagemaster: unsupported filter operator " + op)
+}
+
+// matchEq follows mongodb equality semantics for missing fields and arrays
+func matchEq(field, v bson.RawValue) bool {
+	for _, f := range candidates(field) {
+		if typeOrder(f.Type) == typeOrder(v.Type) && compareValues(f, v) == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchCompare only compares values within the same type bracket, as mongodb does
+func matchCompare(field bson.RawValue, op string, v bson.RawValue) bool {
+	for _, f := range candidates(field) {
+		if f.Type == 0 || typeOrder(f.Type) != typeOrder(v.Type) {
+			continue
+		}
+
+		c := compareValues(f, v)
+		switch {
+		case op == "$gt" && c > 0, op == "$gte" && c >= 0, op == "$lt" && c < 0, op == "$lte" && c <= 0:
+			return true
+		}
+	}
+
+	return false
+}
+
+func candidates(field bson.RawValue) []bson.RawValue {
+	c := []bson.RawValue{field}
+	if field.Type != bsontype.Array {
+		return c
+	}
+
+	values, err := field.Array().Values()
+	if err != nil {
+		return c
+	}
+
+	return append(values, field)
+}
+
+func truthy(v bson.RawValue) bool {
+	switch v.Type {
+	case bsontype.Boolean:
+		return v.Boolean()
+	case bsontype.Null, bsontype.Undefined:
+		return false
+	case bsontype.Int32, bsontype.Int64, bsontype.Double:
+		return rawFloat(v) != 0
+	}
+
+	return true
+}
@@ -0,0 +1,102 @@
+package pagemaster
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func Test_combineFilters(t *testing.T) {
+	testID := primitive.NewObjectID()
+
+	tests := []struct {
+		name    string
+		filters []interface{}
+		want    bson.M
+	}{
+		0: {
+			name: "it should be empty without filters",
+			want: bson.M{},
+		},
+		1: {
+			name:    "it should skip empty filters",
+			filters: []interface{}{nil, bson.M{}, bson.D{}, bson.M{"status": "active"}},
+			want:    bson.M{"status": "active"},
+		},
+		2: {
+			name:    "it should keep both _id constraints",
+			filters: []interface{}{bson.M{"_id": bson.M{"$in": bson.A{testID}}}, bson.M{"_id": bson.M{"$lt": testID}}},
+			want: bson.M{"$and": bson.A{
+				bson.M{"_id": bson.M{"$in": bson.A{testID}}},
+				bson.M{"_id": bson.M{"$lt": testID}},
+			}},
+		},
+		3: {
+			name:    "it should wrap a lone bson.D filter",
+			filters: []interface{}{bson.D{{Key: "status", Value: "active"}}},
+			want:    bson.M{"$and": bson.A{bson.D{{Key: "status", Value: "active"}}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := combineFilters(tt.filters...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("combineFilters() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_matchFilter(t *testing.T) {
+	doc, _ := bson.Marshal(bson.D{
+		{Key: "_id", Value: int32(7)},
+		{Key: "status", Value: "active"},
+		{Key: "price", Value: 12.5},
+		{Key: "tags", Value: bson.A{"a", "b"}},
+		{Key: "customer", Value: bson.D{{Key: "name", Value: "x"}}},
+	})
+
+	tests := []struct {
+		name    string
+		filter  interface{}
+		want    bool
+		wantErr bool
+	}{
+		0:  {name: "empty filters match", filter: bson.M{}, want: true},
+		1:  {name: "equality matches", filter: bson.M{"status": "active"}, want: true},
+		2:  {name: "equality mismatches", filter: bson.M{"status": "closed"}, want: false},
+		3:  {name: "numbers compare across types", filter: bson.M{"price": bson.M{"$gt": 12, "$lte": int64(13)}}, want: true},
+		4:  {name: "comparisons respect type brackets", filter: bson.M{"price": bson.M{"$lt": "z"}}, want: false},
+		5:  {name: "arrays match any element", filter: bson.M{"tags": "b"}, want: true},
+		6:  {name: "$in matches array elements", filter: bson.M{"tags": bson.M{"$in": bson.A{"c", "a"}}}, want: true},
+		7:  {name: "$nin excludes", filter: bson.M{"status": bson.M{"$nin": bson.A{"active"}}}, want: false},
+		8:  {name: "dotted keys match embedded fields", filter: bson.M{"customer.name": "x"}, want: true},
+		9:  {name: "missing fields equal null", filter: bson.M{"deletedAt": nil}, want: true},
+		10: {name: "$exists checks presence", filter: bson.M{"deletedAt": bson.M{"$exists": true}}, want: false},
+		11: {
+			name:   "$and requires every clause",
+			filter: bson.M{"$and": bson.A{bson.M{"_id": bson.M{"$lt": 10}}, bson.M{"_id": bson.M{"$gt": 7}}}},
+			want:   false,
+		},
+		12: {
+			name:   "$or requires any clause",
+			filter: bson.M{"$or": bson.A{bson.M{"status": "closed"}, bson.M{"_id": 7}}},
+			want:   true,
+		},
+		13: {name: "$nor rejects matching clauses", filter: bson.M{"$nor": bson.A{bson.M{"status": "active"}}}, want: false},
+		14: {name: "unsupported operators error", filter: bson.M{"status": bson.M{"$regex": "^a"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matchFilter(bson.Raw(doc), tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("matchFilter() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("matchFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
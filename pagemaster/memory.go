@@ -0,0 +1,318 @@
+package pagemaster
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// MemoryStore is a Store over an in-memory slice of documents
+type MemoryStore struct {
+	documents []bson.Raw
+}
+
+// NewMemoryStore instantiates a new MemoryStore holding the passed in documents
+func NewMemoryStore(docs []interface{}) (*MemoryStore, error) {
+	s := &MemoryStore{documents: make([]bson.Raw, 0, len(docs))}
+
+	for _, d := range docs {
+		b, err := bson.Marshal(d)
+		if err != nil {
+			return nil, err
+		}
+		s.documents = append(s.documents, bson.Raw(b))
+	}
+
+	return s, nil
+}
+
+// Find returns a page of documents from the in-memory slice
+func (s *MemoryStore) Find(ctx context.Context, q *Query) ([]bson.Raw, error) {
+	results := make([]bson.Raw, 0)
+
+	if err := ctx.Err(); err != nil {
+		return results, err
+	}
+
+	order := normalizeSort(q.Sort)
+	after, err := rawValues(q.After)
+	if err != nil {
+		return results, err
+	}
+
+	for _, d := range s.documents {
+		if after != nil && compareSort(rawSortValues(d, order), after, order) <= 0 {
+			continue
+		}
+
+		ok, err := matchFilter(d, q.Filter)
+		if err != nil {
+			return results, err
+		}
+
+		if ok {
+			results = append(results, d)
+		}
+	}
+
+	sortRaw(results, order)
+
+	if q.Skip >= int64(len(results)) {
+		results = results[:0]
+	} else if q.Skip > 0 {
+		results = results[q.Skip:]
+	}
+
+	if q.Limit > 0 && int64(len(results)) > q.Limit {
+		results = results[:q.Limit]
+	}
+
+	for i, d := range results {
+		results[i], err = projectRaw(d, q.Fields)
+		if err != nil {
+			return results, err
+		}
+	}
+
+	return results, nil
+}
+
+// Count returns the number of documents matching the filter
+func (s *MemoryStore) Count(ctx context.Context, filter interface{}) (int64, error) {
+	var n int64
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, d := range s.documents {
+		ok, err := matchFilter(d, filter)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// EstimatedCount returns the number of documents in the store
+func (s *MemoryStore) EstimatedCount(ctx context.Context) (int64, error) {
+	return int64(len(s.documents)), ctx.Err()
+}
+
+func sortRaw(docs []bson.Raw, s []SortField) {
+	keys := make([][]bson.RawValue, len(docs))
+	for i, d := range docs {
+		keys[i] = rawSortValues(d, s)
+	}
+
+	sort.Sort(rawSorter{docs: docs, keys: keys, sort: s})
+}
+
+type rawSorter struct {
+	docs []bson.Raw
+	keys [][]bson.RawValue
+	sort []SortField
+}
+
+func (r rawSorter) Len() int {
+	return len(r.docs)
+}
+
+func (r rawSorter) Less(i, j int) bool {
+	return compareSort(r.keys[i], r.keys[j], r.sort) < 0
+}
+
+func (r rawSorter) Swap(i, j int) {
+	r.docs[i], r.docs[j] = r.docs[j], r.docs[i]
+	r.keys[i], r.keys[j] = r.keys[j], r.keys[i]
+}
+
+func rawSortValues(doc bson.Raw, s []SortField) []bson.RawValue {
+	v := make([]bson.RawValue, len(s))
+	for i, f := range s {
+		v[i] = lookupField(doc, f.Key)
+	}
+
+	return v
+}
+
+// rawValues marshals go values into bson values so they can be compared with document fields
+func rawValues(values []interface{}) ([]bson.RawValue, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	rv := make([]bson.RawValue, len(values))
+	for i, v := range values {
+		if isNullValue(v) {
+			rv[i] = bson.RawValue{Type: bsontype.Null}
+			continue
+		}
+
+		t, b, err := bson.MarshalValue(v)
+		if err != nil {
+			return nil, err
+		}
+		rv[i] = bson.RawValue{Type: t, Value: b}
+	}
+
+	return rv, nil
+}
+
+// compareValues orders two bson values the way mongodb sorts them
+func compareValues(a, b bson.RawValue) int {
+	ta, tb := typeOrder(a.Type), typeOrder(b.Type)
+	if ta != tb {
+		return compareInts(int64(ta), int64(tb))
+	}
+
+	switch ta {
+	case 3:
+		return compareNumbers(a, b)
+	case 4:
+		return strings.Compare(rawString(a), rawString(b))
+	case 7:
+		sa, da := a.Binary()
+		sb, db := b.Binary()
+		if len(da) != len(db) {
+			return compareInts(int64(len(da)), int64(len(db)))
+		}
+		if sa != sb {
+			return compareInts(int64(sa), int64(sb))
+		}
+		return bytes.Compare(da, db)
+	case 8:
+		oa, ob := a.ObjectID(), b.ObjectID()
+		return bytes.Compare(oa[:], ob[:])
+	case 9:
+		ba, bb := a.Boolean(), b.Boolean()
+		if ba == bb {
+			return 0
+		}
+		if !ba {
+			return -1
+		}
+		return 1
+	case 10:
+		return compareInts(a.DateTime(), b.DateTime())
+	case 11:
+		at, ai := a.Timestamp()
+		bt, bi := b.Timestamp()
+		if at != bt {
+			return compareInts(int64(at), int64(bt))
+		}
+		return compareInts(int64(ai), int64(bi))
+	case 1, 2, 13:
+		return 0
+	}
+
+	return bytes.Compare(a.Value, b.Value)
+}
+
+func typeOrder(t bsontype.Type) int {
+	switch t {
+	case bsontype.MinKey:
+		return 1
+	case 0, bsontype.Null, bsontype.Undefined:
+		return 2
+	case bsontype.Double, bsontype.Int32, bsontype.Int64, bsontype.Decimal128:
+		return 3
+	case bsontype.String, bsontype.Symbol:
+		return 4
+	case bsontype.EmbeddedDocument:
+		return 5
+	case bsontype.Array:
+		return 6
+	case bsontype.Binary:
+		return 7
+	case bsontype.ObjectID:
+		return 8
+	case bsontype.Boolean:
+		return 9
+	case bsontype.DateTime:
+		return 10
+	case bsontype.Timestamp:
+		return 11
+	case bsontype.Regex:
+		return 12
+	case bsontype.MaxKey:
+		return 13
+	}
+
+	return 14
+}
+
+func compareNumbers(a, b bson.RawValue) int {
+	ia, aok := rawInt(a)
+	ib, bok := rawInt(b)
+	if aok && bok {
+		return compareInts(ia, ib)
+	}
+
+	fa, fb := rawFloat(a), rawFloat(b)
+	switch {
+	case fa < fb:
+		return -1
+	case fa > fb:
+		return 1
+	}
+
+	return 0
+}
+
+func rawInt(v bson.RawValue) (int64, bool) {
+	switch v.Type {
+	case bsontype.Int32:
+		return int64(v.Int32()), true
+	case bsontype.Int64:
+		return v.Int64(), true
+	}
+
+	return 0, false
+}
+
+func rawFloat(v bson.RawValue) float64 {
+	switch v.Type {
+	case bsontype.Double:
+		return v.Double()
+	case bsontype.Int32:
+		return float64(v.Int32())
+	case bsontype.Int64:
+		return float64(v.Int64())
+	case bsontype.Decimal128:
+		f, err := strconv.ParseFloat(v.Decimal128().String(), 64)
+		if err == nil {
+			return f
+		}
+	}
+
+	return math.NaN()
+}
+
+func rawString(v bson.RawValue) string {
+	if v.Type == bsontype.Symbol {
+		return v.Symbol()
+	}
+	return v.StringValue()
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
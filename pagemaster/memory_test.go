@@ -0,0 +1,702 @@
+package pagemaster
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func memoryTestDocuments(n int) []interface{} {
+	docs := make([]interface{}, 0)
+
+	for i := 0; i < n; i++ {
+		d := bson.D{
+			0: bson.E{Key: "_id", Value: primitive.NewObjectIDFromTimestamp(time.Now())},
+			1: bson.E{Key: "createdAt", Value: time.Now().Unix()},
+			2: bson.E{Key: "rev", Value: int32(i)},
+		}
+		docs = append(docs, d)
+	}
+
+	return docs
+}
+
+func TestMemoryStore_Find(t *testing.T) {
+	testDocs := memoryTestDocuments(120)
+	store, err := NewMemoryStore(testDocs)
+	if err != nil {
+		t.Errorf("NewMemoryStore() error = %v", err)
+		return
+	}
+
+	tests := []struct {
+		name     string
+		query    *Query
+		wantLen  int
+		wantHead int
+	}{
+		0: {
+			name:     "it should return the newest documents first",
+			query:    &Query{Limit: 50},
+			wantLen:  50,
+			wantHead: 119,
+		},
+		1: {
+			name:     "it should start after the from key",
+			query:    &Query{After: []interface{}{testDocs[70].(bson.D)[0].Value}, Limit: 50},
+			wantLen:  50,
+			wantHead: 69,
+		},
+		2: {
+			name:     "it should return a short final page",
+			query:    &Query{After: []interface{}{testDocs[20].(bson.D)[0].Value}, Limit: 50},
+			wantLen:  20,
+			wantHead: 19,
+		},
+		3: {
+			name:     "it should return everything when there is no limit",
+			query:    &Query{},
+			wantLen:  120,
+			wantHead: 119,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.Find(context.TODO(), tt.query)
+			if err != nil {
+				t.Errorf("MemoryStore.Find() error = %v", err)
+				return
+			}
+
+			if len(got) != tt.wantLen {
+				t.Errorf("MemoryStore.Find() unexpected result length = got %v, want %v", len(got), tt.wantLen)
+				return
+			}
+
+			for i, d := range got {
+				x := d.Lookup("_id").ObjectID()
+				y := testDocs[tt.wantHead-i].(bson.D)[0].Value
+				if !reflect.DeepEqual(x, y) {
+					t.Errorf("MemoryStore.Find() unexpected value = got %v, want %v", x, y)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestPageMaster_FindPaginated_MemoryStore(t *testing.T) {
+	testDocs := memoryTestDocuments(120)
+	store, err := NewMemoryStore(testDocs)
+	if err != nil {
+		t.Errorf("NewMemoryStore() error = %v", err)
+		return
+	}
+
+	r, _ := http.NewRequest("GET", "/things?pageSize=50", nil)
+	p, err := New(&NewOptions{
+		Request: r,
+		Store:   store,
+	})
+	if err != nil {
+		t.Errorf("New() error = %v", err)
+		return
+	}
+
+	seen := 0
+	for page := 0; page < 4; page++ {
+		got, err := p.FindPaginated()
+		if err != nil {
+			t.Errorf("PageMaster.FindPaginated() error = %v", err)
+			return
+		}
+
+		for _, d := range got {
+			x := d.(bson.D)[0].Value
+			y := testDocs[len(testDocs)-1-seen].(bson.D)[0].Value
+			if !reflect.DeepEqual(x, y) {
+				t.Errorf("PageMaster.FindPaginated() unexpected value on page %v = got %v, want %v", page, x, y)
+				return
+			}
+			seen++
+		}
+
+		if !p.HasMore() {
+			break
+		}
+
+		cur, err := p.Codec().Decode(p.NextToken())
+		if err != nil {
+			t.Errorf("CursorCodec.Decode() error = %v", err)
+			return
+		}
+		p.from = cur.Values
+	}
+
+	if seen != len(testDocs) {
+		t.Errorf("PageMaster.FindPaginated() unexpected document count = got %v, want %v", seen, len(testDocs))
+	}
+}
+
+func TestPageMaster_FindPaginated_MemoryStoreSort(t *testing.T) {
+	docs := make([]interface{}, 0)
+	for i := 0; i < 95; i++ {
+		d := bson.D{
+			{Key: "_id", Value: primitive.NewObjectID()},
+			{Key: "price", Value: int32(i % 7)},
+		}
+		switch {
+		case i%5 == 0:
+			d = append(d, bson.E{Key: "name", Value: nil})
+		case i%4 != 0:
+			d = append(d, bson.E{Key: "name", Value: string(rune('a' + i%3))})
+		}
+		docs = append(docs, d)
+	}
+
+	store, err := NewMemoryStore(docs)
+	if err != nil {
+		t.Errorf("NewMemoryStore() error = %v", err)
+		return
+	}
+
+	tests := []struct {
+		name string
+		sort []SortField
+	}{
+		0: {
+			name: "it should page through colliding sort values without skipping or repeating",
+			sort: []SortField{{Key: "price", Direction: Ascending}},
+		},
+		1: {
+			name: "it should page through mixed sort directions",
+			sort: []SortField{{Key: "price", Direction: Descending}, {Key: "name", Direction: Ascending}},
+		},
+		2: {
+			name: "it should page through missing and null values ascending",
+			sort: []SortField{{Key: "name", Direction: Ascending}},
+		},
+		3: {
+			name: "it should page through missing and null values descending",
+			sort: []SortField{{Key: "name", Direction: Descending}, {Key: "price", Direction: Ascending}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, _ := store.Find(context.TODO(), &Query{Sort: tt.sort})
+
+			r, _ := http.NewRequest("GET", "/things?pageSize=10", nil)
+			p, err := New(&NewOptions{
+				Request: r,
+				Sort:    tt.sort,
+				Store:   store,
+			})
+			if err != nil {
+				t.Errorf("New() error = %v", err)
+				return
+			}
+
+			seen := 0
+			for {
+				got, err := p.FindPaginated()
+				if err != nil {
+					t.Errorf("PageMaster.FindPaginated() error = %v", err)
+					return
+				}
+
+				for _, d := range got {
+					x := d.(bson.D)[0].Value
+					y := want[seen].Lookup("_id").ObjectID()
+					if !reflect.DeepEqual(x, y) {
+						t.Errorf("PageMaster.FindPaginated() unexpected value at %v = got %v, want %v", seen, x, y)
+						return
+					}
+					seen++
+				}
+
+				if p.NextToken() == "" {
+					break
+				}
+
+				p, err = New(&NewOptions{
+					FromToken: p.NextToken(),
+					Request:   r,
+					Sort:      tt.sort,
+					Store:     store,
+				})
+				if err != nil {
+					t.Errorf("New() error = %v", err)
+					return
+				}
+			}
+
+			if seen != len(docs) {
+				t.Errorf("PageMaster.FindPaginated() unexpected document count = got %v, want %v", seen, len(docs))
+			}
+		})
+	}
+}
+
+func TestPageMaster_FindPaginated_MemoryStoreBefore(t *testing.T) {
+	testDocs := memoryTestDocuments(25)
+	store, err := NewMemoryStore(testDocs)
+	if err != nil {
+		t.Errorf("NewMemoryStore() error = %v", err)
+		return
+	}
+
+	fetch := func(query string) (*PageMaster, []interface{}) {
+		r, _ := http.NewRequest("GET", "/things?pageSize=10"+query, nil)
+		p, err := New(&NewOptions{Request: r, Store: store})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		got, err := p.FindPaginated()
+		if err != nil {
+			t.Fatalf("PageMaster.FindPaginated() error = %v", err)
+		}
+		return p, got
+	}
+
+	first, firstPage := fetch("")
+	second, secondPage := fetch("&from=" + first.NextToken())
+	back, backPage := fetch("&before=" + second.PrevToken())
+	last, _ := fetch("&from=" + second.NextToken())
+
+	tests := []struct {
+		name     string
+		got      bool
+		want     bool
+		gotPage  []interface{}
+		wantPage []interface{}
+	}{
+		0: {name: "the first page should have a next page", got: first.HasNext(), want: true},
+		1: {name: "the first page should not have a previous page", got: first.HasPrev(), want: false},
+		2: {name: "the first page should not have a previous token", got: first.PrevToken() != "", want: false},
+		3: {name: "the second page should have a previous page", got: second.HasPrev(), want: true},
+		4: {name: "paging back should have a next page", got: back.HasNext(), want: true},
+		5: {
+			name:     "paging back should return the first page in canonical order",
+			got:      true,
+			want:     true,
+			gotPage:  backPage,
+			wantPage: firstPage,
+		},
+		6:  {name: "paging back to the first page should not have a previous page", got: back.HasPrev(), want: false},
+		7:  {name: "paging back to the first page should not have a previous token", got: back.PrevToken() != "", want: false},
+		8:  {name: "the second page should not repeat the first", got: reflect.DeepEqual(firstPage, secondPage), want: false},
+		9:  {name: "the last page should not have more", got: last.HasMore() || last.HasNext(), want: false},
+		10: {name: "the last page should have an empty next token", got: last.NextToken() != "", want: false},
+		11: {name: "the last page should have a previous token", got: last.PrevToken() != "", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.gotPage, tt.wantPage) {
+				t.Errorf("PageMaster.FindPaginated() = %v, want %v", tt.gotPage, tt.wantPage)
+			}
+		})
+	}
+}
+
+type memoryTestDocument struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	CreatedAt int64              `bson:"createdAt"`
+	Rev       int                `bson:"rev"`
+}
+
+func TestFind_MemoryStore(t *testing.T) {
+	testDocs := memoryTestDocuments(30)
+	store, err := NewMemoryStore(testDocs)
+	if err != nil {
+		t.Errorf("NewMemoryStore() error = %v", err)
+		return
+	}
+
+	r, _ := http.NewRequest("GET", "/things?pageSize=20", nil)
+	p, err := New(&NewOptions{Request: r, Store: store})
+	if err != nil {
+		t.Errorf("New() error = %v", err)
+		return
+	}
+
+	got, err := Find[memoryTestDocument](p)
+	if err != nil {
+		t.Errorf("Find() error = %v", err)
+		return
+	}
+
+	if len(got) != 20 {
+		t.Errorf("Find() unexpected result length = got %v, want %v", len(got), 20)
+		return
+	}
+
+	for i, d := range got {
+		want := testDocs[len(testDocs)-1-i].(bson.D)
+		if d.ID != want[0].Value || d.Rev != int(want[2].Value.(int32)) {
+			t.Errorf("Find() unexpected value = got %v, want %v", d, want)
+			break
+		}
+	}
+
+	cur, err := p.Codec().Decode(p.NextToken())
+	if err != nil {
+		t.Errorf("CursorCodec.Decode() error = %v", err)
+		return
+	}
+
+	if !reflect.DeepEqual(cur.Values, []interface{}{got[len(got)-1].ID}) {
+		t.Errorf("Find() unexpected next token = got %v, want %v", cur.Values, got[len(got)-1].ID)
+	}
+}
+
+func TestPageMaster_FindPaginated_MemoryStoreFilter(t *testing.T) {
+	testDocs := memoryTestDocuments(40)
+	store, err := NewMemoryStore(testDocs)
+	if err != nil {
+		t.Errorf("NewMemoryStore() error = %v", err)
+		return
+	}
+
+	r, _ := http.NewRequest("GET", "/things?pageSize=5", nil)
+	p, err := New(&NewOptions{
+		Filter:  bson.M{"rev": bson.M{"$gte": 10, "$lt": 20}},
+		Request: r,
+		Store:   store,
+	})
+	if err != nil {
+		t.Errorf("New() error = %v", err)
+		return
+	}
+
+	want := int32(19)
+	for page := 0; ; page++ {
+		got, err := p.FindPaginated()
+		if err != nil {
+			t.Errorf("PageMaster.FindPaginated() error = %v", err)
+			return
+		}
+
+		for _, d := range got {
+			if rev := d.(bson.D)[2].Value; rev != want {
+				t.Errorf("PageMaster.FindPaginated() unexpected value on page %v = got %v, want %v", page, rev, want)
+				return
+			}
+			want--
+		}
+
+		if !p.HasMore() {
+			break
+		}
+
+		cur, _ := p.Codec().Decode(p.NextToken())
+		p.from = cur.Values
+	}
+
+	if want != 9 {
+		t.Errorf("PageMaster.FindPaginated() stopped early at rev %v", want)
+	}
+}
+
+func TestPageMaster_Total_MemoryStore(t *testing.T) {
+	store, err := NewMemoryStore(memoryTestDocuments(60))
+	if err != nil {
+		t.Errorf("NewMemoryStore() error = %v", err)
+		return
+	}
+
+	tests := []struct {
+		name      string
+		url       string
+		o         NewOptions
+		want      int64
+		wantTotal bool
+	}{
+		0: {
+			name: "it should not count by default",
+			url:  "/things",
+		},
+		1: {
+			name:      "it should estimate the count without a filter",
+			url:       "/things?includeTotal=true",
+			want:      60,
+			wantTotal: true,
+		},
+		2: {
+			name:      "it should count the filtered query",
+			url:       "/things?pageSize=5",
+			o:         NewOptions{IncludeTotal: true, Filter: bson.M{"rev": bson.M{"$lt": 12}}},
+			want:      12,
+			wantTotal: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := http.NewRequest("GET", tt.url, nil)
+			o := tt.o
+			o.Request = r
+			o.Store = store
+			p, err := New(&o)
+			if err != nil {
+				t.Errorf("New() error = %v", err)
+				return
+			}
+
+			if _, err := p.FindPaginated(); err != nil {
+				t.Errorf("PageMaster.FindPaginated() error = %v", err)
+				return
+			}
+
+			got, ok := p.Total()
+			if got != tt.want || ok != tt.wantTotal {
+				t.Errorf("PageMaster.Total() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestPageMaster_FindPaginated_MemoryStoreFields(t *testing.T) {
+	store, err := NewMemoryStore(memoryTestDocuments(10))
+	if err != nil {
+		t.Errorf("NewMemoryStore() error = %v", err)
+		return
+	}
+
+	r, _ := http.NewRequest("GET", "/things?pageSize=4&fields=rev", nil)
+	p, err := New(&NewOptions{
+		Request:          r,
+		SelectableFields: []string{"rev"},
+		Sort:             []SortField{{Key: "createdAt", Direction: Descending}},
+		Store:            store,
+	})
+	if err != nil {
+		t.Errorf("New() error = %v", err)
+		return
+	}
+
+	got, err := p.FindPaginated()
+	if err != nil {
+		t.Errorf("PageMaster.FindPaginated() error = %v", err)
+		return
+	}
+
+	for _, d := range got {
+		keys := make([]string, 0)
+		for _, e := range d.(bson.D) {
+			keys = append(keys, e.Key)
+		}
+		if want := []string{"_id", "createdAt", "rev"}; !reflect.DeepEqual(keys, want) {
+			t.Errorf("PageMaster.FindPaginated() unexpected fields = got %v, want %v", keys, want)
+			return
+		}
+	}
+
+	if p.NextToken() == "" {
+		t.Errorf("PageMaster.FindPaginated() expected a next token from the projected documents")
+	}
+}
+
+func TestPageMaster_FindPaginated_MemoryStoreSortParameter(t *testing.T) {
+	store, err := NewMemoryStore(memoryTestDocuments(12))
+	if err != nil {
+		t.Errorf("NewMemoryStore() error = %v", err)
+		return
+	}
+
+	fetch := func(query string) *PageMaster {
+		r, _ := http.NewRequest("GET", "/things?pageSize=5"+query, nil)
+		p, err := New(&NewOptions{Request: r, SortableFields: []string{"rev"}, Store: store})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		got, err := p.FindPaginated()
+		if err != nil {
+			t.Fatalf("PageMaster.FindPaginated() error = %v", err)
+		}
+		if first := got[0].(bson.D)[2].Value; p.from == nil && first != int32(0) {
+			t.Errorf("PageMaster.FindPaginated() unexpected first value = got %v, want %v", first, 0)
+		}
+		return p
+	}
+
+	p := fetch("&sort=rev")
+	want := []SortField{{Key: "rev", Direction: Ascending}, {Key: "_id", Direction: Ascending}}
+	for page := 1; p.HasMore(); page++ {
+		p = fetch("&from=" + p.NextToken())
+		if !reflect.DeepEqual(p.sort, want) {
+			t.Errorf("PageMaster.sort on page %v = %v, want %v", page, p.sort, want)
+			return
+		}
+	}
+}
+
+func TestPageMaster_FindPaginated_MemoryStoreOffset(t *testing.T) {
+	store, err := NewMemoryStore(memoryTestDocuments(23))
+	if err != nil {
+		t.Errorf("NewMemoryStore() error = %v", err)
+		return
+	}
+
+	tests := []struct {
+		name      string
+		url       string
+		wantRevs  []interface{}
+		wantNext  bool
+		wantPrev  bool
+		wantPage  int64
+		wantPages int64
+		wantErr   bool
+	}{
+		0: {
+			name:      "it should default to the first page",
+			url:       "/things?pageSize=5",
+			wantRevs:  []interface{}{int32(22), int32(21), int32(20), int32(19), int32(18)},
+			wantNext:  true,
+			wantPage:  1,
+			wantPages: 5,
+		},
+		1: {
+			name:      "it should jump to a page",
+			url:       "/things?pageSize=5&page=3",
+			wantRevs:  []interface{}{int32(12), int32(11), int32(10), int32(9), int32(8)},
+			wantNext:  true,
+			wantPrev:  true,
+			wantPage:  3,
+			wantPages: 5,
+		},
+		2: {
+			name:      "it should return a short last page",
+			url:       "/things?pageSize=5&page=5",
+			wantRevs:  []interface{}{int32(2), int32(1), int32(0)},
+			wantPrev:  true,
+			wantPage:  5,
+			wantPages: 5,
+		},
+		3: {
+			name:    "it should reject pages past the maximum offset",
+			url:     "/things?pageSize=5&page=22",
+			wantErr: true,
+		},
+		4: {
+			name:    "it should reject pages that are not positive",
+			url:     "/things?pageSize=5&page=0",
+			wantErr: true,
+		},
+		5: {
+			name:    "it should reject pages whose offset overflows",
+			url:     "/things?pageSize=100&page=100000000000000001",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := http.NewRequest("GET", tt.url, nil)
+			p, err := New(&NewOptions{MaxOffset: 100, Mode: OffsetMode, Request: r, Store: store})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got, err := p.FindPaginated()
+			if err != nil {
+				t.Errorf("PageMaster.FindPaginated() error = %v", err)
+				return
+			}
+
+			revs := make([]interface{}, 0)
+			for _, d := range got {
+				revs = append(revs, d.(bson.D)[2].Value)
+			}
+
+			if !reflect.DeepEqual(revs, tt.wantRevs) {
+				t.Errorf("PageMaster.FindPaginated() = %v, want %v", revs, tt.wantRevs)
+			}
+			if p.HasNext() != tt.wantNext || p.HasPrev() != tt.wantPrev {
+				t.Errorf("PageMaster.HasNext(), HasPrev() = %v, %v, want %v, %v", p.HasNext(), p.HasPrev(), tt.wantNext, tt.wantPrev)
+			}
+			if p.CurrentPage() != tt.wantPage || p.TotalPages() != tt.wantPages {
+				t.Errorf("PageMaster.CurrentPage(), TotalPages() = %v, %v, want %v, %v", p.CurrentPage(), p.TotalPages(), tt.wantPage, tt.wantPages)
+			}
+		})
+	}
+}
+
+func TestPageMaster_FindPaginated_MemoryStoreKeys(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		key  func(i int) interface{}
+	}{
+		0: {name: "it should page through string keys", key: func(i int) interface{} { return fmt.Sprintf("key-%03d", i) }},
+		1: {name: "it should page through int32 keys", key: func(i int) interface{} { return int32(i) }},
+		2: {name: "it should page through int64 keys", key: func(i int) interface{} { return int64(i) << 33 }},
+		3: {name: "it should page through object id keys", key: func(i int) interface{} {
+			return primitive.NewObjectIDFromTimestamp(start.Add(time.Duration(i) * time.Second))
+		}},
+		4: {name: "it should page through uuid keys", key: func(i int) interface{} {
+			return primitive.Binary{Subtype: 0x04, Data: []byte(fmt.Sprintf("uuid-%011d", i))}
+		}},
+		5: {name: "it should page through time keys", key: func(i int) interface{} { return start.Add(time.Duration(i) * time.Hour) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs := make([]interface{}, 0)
+			for i := 0; i < 25; i++ {
+				docs = append(docs, bson.D{{Key: "_id", Value: tt.key(i)}, {Key: "rev", Value: int32(i)}})
+			}
+
+			store, err := NewMemoryStore(docs)
+			if err != nil {
+				t.Errorf("NewMemoryStore() error = %v", err)
+				return
+			}
+
+			revs := make([]int, 0)
+			token := ""
+			for pages := 0; pages < 5; pages++ {
+				r, _ := http.NewRequest("GET", "/things?pageSize=10&from="+token, nil)
+				p, err := New(&NewOptions{Request: r, Store: store})
+				if err != nil {
+					t.Errorf("New() error = %v", err)
+					return
+				}
+
+				got, err := Find[struct {
+					Rev int `bson:"rev"`
+				}](p)
+				if err != nil {
+					t.Errorf("Find() error = %v", err)
+					return
+				}
+				for _, d := range got {
+					revs = append(revs, d.Rev)
+				}
+
+				token = p.NextToken()
+				if token == "" {
+					break
+				}
+			}
+
+			want := make([]int, 0)
+			for i := 24; i >= 0; i-- {
+				want = append(want, i)
+			}
+			if !reflect.DeepEqual(revs, want) {
+				t.Errorf("Find() revs = %v, want %v", revs, want)
+			}
+		})
+	}
+}
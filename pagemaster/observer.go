@@ -0,0 +1,120 @@
+package pagemaster
+
+import (
+	"context"
+	"time"
+
+	"github.com/joeyfromspace/go-api-util/v2/logger"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// QueryPage, QueryCount and QuerySnapshot are the operations a QueryEvent describes
+const (
+	QueryPage     = "page"
+	QueryCount    = "count"
+	QuerySnapshot = "snapshot"
+)
+
+// QueryEvent describes a query run by a PageMaster
+type QueryEvent struct {
+	Collection string
+	Count      int
+	Duration   time.Duration
+	Err        error
+	Filter     interface{}
+	Operation  string
+	PageSize   int64
+	Pipeline   mongo.Pipeline
+	Sort       []SortField
+}
+
+// Observer is notified after every query a PageMaster runs, possibly from several goroutines at once
+type Observer interface {
+	ObserveQuery(e *QueryEvent)
+}
+
+// SlowQueryLogger is an Observer that logs queries taking at least Threshold
+type SlowQueryLogger struct {
+	Logger    *logrus.Logger
+	Threshold time.Duration
+}
+
+// NewSlowQueryLogger instantiates a new SlowQueryLogger
+func NewSlowQueryLogger(threshold time.Duration) *SlowQueryLogger {
+	return &SlowQueryLogger{Logger: logger.Log(), Threshold: threshold}
+}
+
+// ObserveQuery logs the query when it is slower than the threshold
+func (l *SlowQueryLogger) ObserveQuery(e *QueryEvent) {
+	if l.Logger == nil || e.Duration < l.Threshold {
+		return
+	}
+
+	entry := l.Logger.WithFields(logrus.Fields{
+		"collection": e.Collection,
+		"count":      e.Count,
+		"duration":   e.Duration,
+		"filter":     e.Filter,
+		"operation":  e.Operation,
+		"pageSize":   e.PageSize,
+		"pipeline":   e.Pipeline,
+		"sort":       e.Sort,
+	})
+
+	if e.Err != nil {
+		entry = entry.WithError(e.Err)
+	}
+
+	entry.Warn("pagemaster: slow query")
+}
+
+// observe reports a finished query to the PageMaster's observer
+func (p *PageMaster) observe(e *QueryEvent, start time.Time, err error) {
+	if p.observer == nil {
+		return
+	}
+
+	e.Collection = p.collection
+	e.Duration = now().Sub(start)
+	e.Err = err
+	e.PageSize = p.pageSize
+	p.observer.ObserveQuery(e)
+}
+
+// observeFind wraps a query so every call is reported to the Observer
+func (p *PageMaster) observeFind(op string, find func(context.Context, *Query) ([]bson.Raw, error), pipeline mongo.Pipeline) func(context.Context, *Query) ([]bson.Raw, error) {
+	return func(ctx context.Context, q *Query) ([]bson.Raw, error) {
+		start := now()
+		docs, err := find(ctx, q)
+
+		e := &QueryEvent{Count: len(docs), Operation: op, Sort: normalizeSort(q.Sort)}
+		if pipeline != nil {
+			e.Pipeline = injectStages(pipeline, paginationStages(q))
+		} else {
+			e.Filter = combineFilters(q.Filter, keysetFilter(e.Sort, q.After))
+		}
+		p.observe(e, start, err)
+
+		return docs, err
+	}
+}
+
+// observeCount wraps a count so every call is reported like observeFind reports queries
+func (p *PageMaster) observeCount(count func(context.Context) (int64, error), pipeline mongo.Pipeline) func(context.Context) (int64, error) {
+	return func(ctx context.Context) (int64, error) {
+		start := now()
+		n, err := count(ctx)
+
+		e := &QueryEvent{Count: int(n), Operation: QueryCount}
+		if pipeline != nil {
+			e.Pipeline = countPipeline(pipeline, p.queryFilter())
+		} else {
+			e.Filter = p.queryFilter()
+		}
+		p.observe(e, start, err)
+
+		return n, err
+	}
+}
@@ -0,0 +1,195 @@
+package pagemaster
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joeyfromspace/go-api-util/v2/logger"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type testObserver struct {
+	events []*QueryEvent
+	mu     sync.Mutex
+}
+
+func (o *testObserver) ObserveQuery(e *QueryEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, e)
+}
+
+func TestPageMaster_Observer(t *testing.T) {
+	memory, err := NewMemoryStore(memoryTestDocuments(15))
+	if err != nil {
+		t.Errorf("NewMemoryStore() error = %v", err)
+		return
+	}
+	search := searchTestStore(t)
+	filter := bson.M{"rev": bson.M{"$gte": 0}}
+
+	tests := []struct {
+		name    string
+		options NewOptions
+		query   string
+		wantErr error
+		want    func(p *PageMaster) []*QueryEvent
+	}{
+		0: {
+			name:    "it should observe a successful query",
+			options: NewOptions{Filter: filter, Store: memory},
+			query:   "/things?pageSize=10",
+			want: func(p *PageMaster) []*QueryEvent {
+				return []*QueryEvent{{Count: 11, Filter: filter, Operation: QueryPage, Sort: defaultSort}}
+			},
+		},
+		1: {
+			name:    "it should observe a failed query",
+			options: NewOptions{Store: &testFailingStore{MemoryStore: memory}},
+			query:   "/things?pageSize=10",
+			wantErr: errTestStore,
+			want: func(p *PageMaster) []*QueryEvent {
+				return []*QueryEvent{{Err: errTestStore, Filter: bson.M{}, Operation: QueryPage, Sort: defaultSort}}
+			},
+		},
+		2: {
+			name:    "it should observe the count query",
+			options: NewOptions{Filter: filter, Store: memory},
+			query:   "/things?pageSize=10&includeTotal=true",
+			want: func(p *PageMaster) []*QueryEvent {
+				return []*QueryEvent{
+					{Count: 15, Filter: filter, Operation: QueryCount},
+					{Count: 11, Filter: filter, Operation: QueryPage, Sort: defaultSort},
+				}
+			},
+		},
+		3: {
+			name:    "it should observe the snapshot query",
+			options: NewOptions{Filter: filter, Snapshot: true, Store: memory},
+			query:   "/things?pageSize=10",
+			want: func(p *PageMaster) []*QueryEvent {
+				return []*QueryEvent{
+					{Count: 11, Filter: p.GetMongoDBQueryFilter(), Operation: QueryPage, Sort: defaultSort},
+					{Count: 1, Filter: filter, Operation: QuerySnapshot, Sort: defaultSort},
+				}
+			},
+		},
+		4: {
+			name:    "it should observe the pipeline of a search",
+			options: NewOptions{Mode: SearchMode, Store: search},
+			query:   "/things?pageSize=5&q=coffee",
+			want: func(p *PageMaster) []*QueryEvent {
+				return []*QueryEvent{{Count: 6, Operation: QueryPage, Pipeline: search.pipeline, Sort: normalizeSort(searchSort)}}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &testObserver{}
+			r, _ := http.NewRequest("GET", tt.query, nil)
+			opts := tt.options
+			opts.Collection = "things"
+			opts.Observer = o
+			opts.Request = r
+			p, err := New(&opts)
+			if err != nil {
+				t.Errorf("New() error = %v", err)
+				return
+			}
+
+			_, err = p.FindPaginated()
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("PageMaster.FindPaginated() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			sort.Slice(o.events, func(i, j int) bool { return o.events[i].Operation < o.events[j].Operation })
+			want := tt.want(p)
+			for i, e := range want {
+				e.Collection = "things"
+				e.PageSize = p.pageSize
+				if i < len(o.events) {
+					e.Duration = o.events[i].Duration
+				}
+			}
+			if len(o.events) != len(want) {
+				t.Errorf("Observer events = %v, want %v", len(o.events), len(want))
+				return
+			}
+			for i, e := range o.events {
+				if !reflect.DeepEqual(e, want[i]) {
+					t.Errorf("Observer event = %+v, want %+v", e, want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSlowQueryLogger_ObserveQuery_NoLogger(t *testing.T) {
+	hook := new(test.Hook)
+	logger.Reset()
+	defer logger.Reset()
+	log := logger.Initialize(&logger.Options{Hooks: []logrus.Hook{hook}})
+	log.SetOutput(io.Discard)
+
+	l := &SlowQueryLogger{Threshold: time.Millisecond}
+	l.ObserveQuery(&QueryEvent{Duration: time.Second})
+
+	if entries := hook.AllEntries(); len(entries) != 0 {
+		t.Errorf("SlowQueryLogger.ObserveQuery() logged %v entries without a Logger, want 0", len(entries))
+	}
+}
+
+func TestSlowQueryLogger_ObserveQuery(t *testing.T) {
+	queryErr := errors.New("timed out")
+
+	tests := []struct {
+		name      string
+		event     *QueryEvent
+		wantLog   bool
+		wantError bool
+	}{
+		0: {name: "it should not log a fast query", event: &QueryEvent{Duration: 10 * time.Millisecond}},
+		1: {name: "it should log a slow query", event: &QueryEvent{Collection: "things", Count: 3, Duration: time.Second}, wantLog: true},
+		2: {name: "it should log the error of a slow query", event: &QueryEvent{Duration: time.Second, Err: queryErr}, wantLog: true, wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := new(test.Hook)
+			logger.Reset()
+			defer logger.Reset()
+			log := logger.Initialize(&logger.Options{Hooks: []logrus.Hook{hook}})
+			log.SetOutput(io.Discard)
+
+			NewSlowQueryLogger(500 * time.Millisecond).ObserveQuery(tt.event)
+
+			entries := hook.AllEntries()
+			if (len(entries) > 0) != tt.wantLog {
+				t.Errorf("SlowQueryLogger.ObserveQuery() logged %v entries, wantLog %v", len(entries), tt.wantLog)
+				return
+			}
+			if !tt.wantLog {
+				return
+			}
+
+			e := entries[0]
+			if e.Level != logrus.WarnLevel {
+				t.Errorf("SlowQueryLogger.ObserveQuery() level = %v, want %v", e.Level, logrus.WarnLevel)
+			}
+			if e.Data["collection"] != tt.event.Collection || e.Data["duration"] != tt.event.Duration {
+				t.Errorf("SlowQueryLogger.ObserveQuery() fields = %v", e.Data)
+			}
+			if _, ok := e.Data[logrus.ErrorKey]; ok != tt.wantError {
+				t.Errorf("SlowQueryLogger.ObserveQuery() fields = %v, wantError %v", e.Data, tt.wantError)
+			}
+		})
+	}
+}
@@ -2,43 +2,129 @@ package pagemaster
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"reflect"
 	"strconv"
+	"sync"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
-const maxPageSize int64 = 100
+const (
+	defaultPageSize    int64 = 50
+	defaultMaxPageSize int64 = 100
+	defaultMinPageSize int64 = 1
+)
+
+const defaultMaxOffset int64 = 10000
+
+// Mode selects how a PageMaster moves between pages
+type Mode int
+
+// Supported modes, KeysetMode being the default
+const (
+	KeysetMode Mode = iota
+	OffsetMode
+	SearchMode
+)
+
+func (m Mode) String() string {
+	switch m {
+	case KeysetMode:
+		return "KeysetMode"
+	case OffsetMode:
+		return "OffsetMode"
+	case SearchMode:
+		return "SearchMode"
+	default:
+		return "Mode(" + strconv.Itoa(int(m)) + ")"
+	}
+}
 
 // PageMaster is a pagination struct that scrolls through pages
 type PageMaster struct {
-	collection   string
-	ctx          context.Context
-	database     *mongo.Database
-	from         interface{}
-	nextToken    string
-	pageSize     int64
-	queryTimeout time.Duration
+	backward       bool
+	codec          *CursorCodec
+	collation      *options.Collation
+	collection     string
+	ctx            context.Context
+	database       *mongo.Database
+	docs           []bson.Raw
+	fields         []string
+	filter         interface{}
+	from           []interface{}
+	hasMore        bool
+	hasNext        bool
+	hasPrev        bool
+	hasTotal       bool
+	hint           interface{}
+	includeTotal   bool
+	maxOffset      int64
+	maxTime        time.Duration
+	mode           Mode
+	nextToken      string
+	observer       Observer
+	page           int64
+	pageSize       int64
+	prevToken      string
+	queryTimeout   time.Duration
+	readPreference *readpref.ReadPref
+	search         string
+	snapshot       *SnapshotBound
+	snapshotField  string
+	sort           []SortField
+	store          Store
+	total          int64
+	useSnapshot    bool
 }
 
 // NewOptions used to specify initialization options for a new PageMaster instance
 type NewOptions struct {
-	Collection         string
-	Context            context.Context
-	Database           *mongo.Database
-	FromToken          string
-	PageSize           int64
+	BeforeToken      string
+	Collation        *options.Collation
+	Collection       string
+	Context          context.Context
+	CursorCodec      *CursorCodec
+	Database         *mongo.Database
+	DefaultPageSize  int64
+	Filter           interface{}
+	FilterSchema     FilterSchema
+	FromToken        string
+	Hint             interface{}
+	IncludeTotal     bool
+	MaxOffset        int64
+	MaxPageSize      int64
+	MaxTime          time.Duration
+	MinPageSize      int64
+	Mode             Mode
+	Observer         Observer
+	PageSize         int64
+	QueryTimeout     time.Duration
+	ReadPreference   *readpref.ReadPref
+	Request          *http.Request
+	SelectableFields []string
+	Snapshot         bool
+	SnapshotField    string
+	Sort             []SortField
+	SortableFields   []string
+	Store            Store
+	StrictPageSize   bool
+
+	// Deprecated: UnmarshalInterface is ignored. Use Find to decode results into a type
 	UnmarshalInterface interface{}
-	QueryTimeout       time.Duration
-	Request            *http.Request
 }
 
 // Collection returns the collection associated with the PageMaster object
 func (p *PageMaster) Collection() *mongo.Collection {
+	if p.readPreference != nil {
+		return p.Database().Collection(p.collection, options.Collection().SetReadPreference(p.readPreference))
+	}
+
 	return p.Database().Collection(p.collection)
 }
 
@@ -47,145 +133,624 @@ func (p *PageMaster) Database() *mongo.Database {
 	return p.database
 }
 
-// FindPaginated executes a mongodb query with the paginated items
+// FindPaginated executes a query against the store with the paginated items
 func (p *PageMaster) FindPaginated() ([]interface{}, error) {
-	var lastID primitive.ObjectID
-	results := make([]interface{}, 0)
-	filter := p.GetMongoDBQueryFilter()
-
-	coll := p.Collection()
-
-	ctx, cancel := context.WithTimeout(p.ctx, p.queryTimeout)
-	defer cancel()
+	docs, err := p.find(p.ctx)
+	if err != nil {
+		return make([]interface{}, 0), err
+	}
 
-	cursor, err := coll.Find(ctx, filter, &options.FindOptions{
-		Limit: p.PageSize(),
-		Sort:  bson.M{"_id": -1},
-	})
+	return decodeDocuments(docs)
+}
 
+// Find executes a paginated query like FindPaginated, decoding each document directly into a T
+func Find[T any](p *PageMaster) ([]T, error) {
+	docs, err := p.find(p.ctx)
 	if err != nil {
-		return results, err
+		return make([]T, 0), err
 	}
 
-	ctx, cancel = context.WithTimeout(p.ctx, p.queryTimeout)
-	defer cancel()
-	defer cursor.Close(ctx)
+	return decodeAll[T](docs)
+}
 
-	for cursor.Next(ctx) {
-		var d bson.M
+// decodeDocuments unmarshals each raw document once into a bson.D
+func decodeDocuments(docs []bson.Raw) ([]interface{}, error) {
+	results := make([]interface{}, 0, len(docs))
+
+	for _, doc := range docs {
 		var v bson.D
 
-		err = cursor.Decode(&v)
+		err := bson.Unmarshal(doc, &v)
 		if err != nil {
 			return results, err
 		}
 
-		err = cursor.Decode(&d)
+		results = append(results, v)
+	}
+
+	return results, nil
+}
+
+func decodeAll[T any](docs []bson.Raw) ([]T, error) {
+	results := make([]T, 0, len(docs))
+
+	for _, doc := range docs {
+		var v T
+
+		err := bson.Unmarshal(doc, &v)
 		if err != nil {
 			return results, err
 		}
 
-		lid, ok := d["_id"].(primitive.ObjectID)
-		if ok {
-			lastID = lid
-		}
 		results = append(results, v)
 	}
 
-	if len(results) > 0 {
-		p.nextToken = lastID.Hex()
+	return results, nil
+}
+
+func (p *PageMaster) find(ctx context.Context) ([]bson.Raw, error) {
+	if p.mode == SearchMode {
+		return p.aggregate(ctx, p.searchPipeline())
 	}
 
-	return results, nil
+	return p.fetchPage(ctx, p.observeFind(QueryPage, p.Store().Find, nil), p.observeCount(p.count, nil))
+}
+
+// fetchPage runs the page query and updates the page tokens from the results
+func (p *PageMaster) fetchPage(ctx context.Context, query func(context.Context, *Query) ([]bson.Raw, error), count func(context.Context) (int64, error)) ([]bson.Raw, error) {
+	sort := normalizeSort(p.sort)
+
+	ctx, cancel := context.WithTimeout(ctx, p.queryTimeout)
+	defer cancel()
+
+	limit := p.pageSize
+	if limit > 0 {
+		limit++
+	}
+
+	if p.useSnapshot && p.snapshot == nil {
+		err := p.pinSnapshot(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var wg sync.WaitGroup
+	var countErr error
+	if p.includeTotal {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.total, countErr = count(ctx)
+		}()
+	}
+
+	docs, err := query(ctx, &Query{
+		After:  p.from,
+		Fields: projectionFields(p.fields, sort),
+		Filter: p.queryFilter(),
+		Limit:  limit,
+		Skip:   p.offset(),
+		Sort:   p.querySort(),
+	})
+	wg.Wait()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if countErr != nil {
+		return nil, countErr
+	}
+	if p.includeTotal {
+		p.hasTotal = true
+	}
+
+	p.hasMore = p.pageSize > 0 && int64(len(docs)) > p.pageSize
+	if p.hasMore {
+		docs = docs[:p.pageSize]
+	}
+
+	p.docs = nil
+	p.nextToken = ""
+	p.prevToken = ""
+
+	if p.mode == OffsetMode {
+		p.hasNext = p.hasMore
+		p.hasPrev = p.page > 1
+		return docs, nil
+	}
+
+	if p.backward {
+		for i, j := 0, len(docs)-1; i < j; i, j = i+1, j-1 {
+			docs[i], docs[j] = docs[j], docs[i]
+		}
+		p.hasNext = true
+		p.hasPrev = p.hasMore
+	} else {
+		p.hasNext = p.hasMore
+		p.hasPrev = p.from != nil
+	}
+
+	p.docs = docs
+
+	if len(docs) > 0 && p.hasNext {
+		p.nextToken, err = p.cursorFor(docs[len(docs)-1])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(docs) > 0 && p.hasPrev {
+		p.prevToken, err = p.cursorFor(docs[0])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return docs, nil
+}
+
+// cursorFor encodes a token pointing at a document of the current page
+func (p *PageMaster) cursorFor(doc bson.Raw) (string, error) {
+	sort := normalizeSort(p.sort)
+	return p.Codec().Encode(&Cursor{Search: p.search, Snapshot: p.snapshot, Sort: sort, Values: sortValues(doc, sort)})
+}
+
+// Cursors returns a token for every document of the last page fetched
+func (p *PageMaster) Cursors() ([]string, error) {
+	cursors := make([]string, 0, len(p.docs))
+	if p.mode == OffsetMode {
+		return cursors, nil
+	}
+
+	for _, doc := range p.docs {
+		c, err := p.cursorFor(doc)
+		if err != nil {
+			return nil, err
+		}
+		cursors = append(cursors, c)
+	}
+
+	return cursors, nil
 }
 
 // GetMongoDBQueryFilter creates pagination filters for mongo queries
 func (p *PageMaster) GetMongoDBQueryFilter() bson.M {
-	f := bson.M{}
-	if p.from != nil {
-		f["_id"] = bson.M{"$lt": p.from.(primitive.ObjectID)}
+	return combineFilters(p.queryFilter(), keysetFilter(p.querySort(), p.from))
+}
+
+// count totals the documents matching the base filter
+func (p *PageMaster) count(ctx context.Context) (int64, error) {
+	c, ok := p.Store().(Counter)
+	if !ok {
+		return 0, errors.New("pagemaster: store does not support counting documents")
 	}
 
-	return f
+	filter := p.queryFilter()
+	if isEmptyFilter(filter) {
+		return c.EstimatedCount(ctx)
+	}
+
+	return c.Count(ctx, filter)
 }
 
-// NextToken is the string reprsentation of the next object
+// offset is the number of documents skipped to reach the current page in offset mode
+func (p *PageMaster) offset() int64 {
+	if p.mode != OffsetMode {
+		return 0
+	}
+
+	return (p.page - 1) * p.pageSize
+}
+
+// withinMaxOffset reports whether a page starts at or before maxOffset
+func withinMaxOffset(page, pageSize, maxOffset int64) bool {
+	return page-1 <= maxOffset/pageSize
+}
+
+func errPageBeyondMaxOffset() error {
+	return &RequestError{Parameter: "page", Detail: "page is beyond the last page that can be requested"}
+}
+
+// querySort is the order documents are fetched in, which is reversed when paging backwards
+func (p *PageMaster) querySort() []SortField {
+	sort := normalizeSort(p.sort)
+	if p.backward {
+		return reverseSort(sort)
+	}
+
+	return sort
+}
+
+// Codec returns the codec used to sign and verify cursor tokens
+func (p *PageMaster) Codec() *CursorCodec {
+	if p.codec != nil {
+		return p.codec
+	}
+
+	return DefaultCursorCodec
+}
+
+// HasMore reports whether another page exists in the direction the current page was requested in
+func (p *PageMaster) HasMore() bool {
+	return p.hasMore
+}
+
+// HasNext reports whether there are documents after the current page
+func (p *PageMaster) HasNext() bool {
+	return p.hasNext
+}
+
+// HasPrev reports whether there are documents before the current page
+func (p *PageMaster) HasPrev() bool {
+	return p.hasPrev
+}
+
+// NextToken is an opaque, signed token pointing at the last document on the page
 func (p *PageMaster) NextToken() string {
 	return p.nextToken
 }
 
+// PrevToken is an opaque, signed token pointing at the first document on the page
+func (p *PageMaster) PrevToken() string {
+	return p.prevToken
+}
+
+// Store returns the store the PageMaster pages through
+func (p *PageMaster) Store() Store {
+	if p.store != nil {
+		return p.store
+	}
+
+	return &MongoStore{
+		Collation:      p.collation,
+		Collection:     p.Collection(),
+		Hint:           p.hint,
+		MaxTime:        p.maxTime,
+		ReadPreference: p.readPreference,
+	}
+}
+
+// Total returns the number of documents matching the query when a total was requested
+func (p *PageMaster) Total() (int64, bool) {
+	return p.total, p.hasTotal
+}
+
+// CurrentPage returns the one-based page number in offset mode
+func (p *PageMaster) CurrentPage() int64 {
+	return p.page
+}
+
+// TotalPages returns the number of pages in offset mode
+func (p *PageMaster) TotalPages() int64 {
+	if !p.hasTotal || p.pageSize == 0 {
+		return 0
+	}
+
+	return (p.total + p.pageSize - 1) / p.pageSize
+}
+
+// Mode returns the pagination mode of the PageMaster
+func (p *PageMaster) Mode() Mode {
+	return p.mode
+}
+
 // PageSize returns the page size of the paginated instance
 func (p *PageMaster) PageSize() *int64 {
 	return &p.pageSize
 }
 
 // New instantiates a new PageMaster from passed in options
-func New(o *NewOptions) PageMaster {
+func New(o *NewOptions) (*PageMaster, error) {
 	c := o.Collection
 	r := o.Request
 	d := o.Database
+	st := o.Store
 	from := o.FromToken
+	before := o.BeforeToken
+	includeTotal := o.IncludeTotal
 	pageSize := o.PageSize
 	qt := o.QueryTimeout
 
 	if r == nil {
-		panic("instantiated with no request")
+		return nil, ErrNoRequest
+	}
+
+	if st == nil && d == nil {
+		return nil, ErrNoDatabase
+	}
+
+	if st != nil && (o.Collation != nil || o.Hint != nil || o.MaxTime != 0 || o.ReadPreference != nil) {
+		return nil, ErrStoreOptions
+	}
+
+	switch o.Mode {
+	case KeysetMode, OffsetMode, SearchMode:
+	default:
+		return nil, ErrInvalidMode
 	}
 
-	if d == nil {
-		panic("instantiated with no database")
+	if o.Snapshot && o.Mode != KeysetMode {
+		return nil, ErrSnapshotMode
 	}
 
-	if c == "" {
-		panic("instantiated with no collection name")
+	codec := o.CursorCodec
+	if codec == nil {
+		codec = DefaultCursorCodec
 	}
 
-	if from == "" {
+	if o.Mode != OffsetMode && (codec == nil || len(codec.Keys) == 0) {
+		return nil, ErrNoCursorCodec
+	}
+
+	if st == nil && c == "" {
+		return nil, ErrNoCollection
+	}
+
+	if from == "" && before == "" {
 		from = getFromTokenFromRequest(o.Request)
+		before = getBeforeTokenFromRequest(o.Request)
 	}
 
-	if pageSize == 0 {
-		pageSize = getPageSize(o.Request)
+	if from != "" && before != "" {
+		return nil, &RequestError{Parameter: "before", Detail: "before cannot be combined with from"}
+	}
+
+	if !includeTotal {
+		it, err := getIncludeTotal(o.Request)
+		if err != nil {
+			return nil, err
+		}
+		includeTotal = it
 	}
 
-	if pageSize > maxPageSize {
-		pageSize = maxPageSize
+	fields, err := getFieldsFromRequest(o.Request, o.SelectableFields)
+	if err != nil {
+		return nil, err
+	}
+
+	sort, err := getSortFromRequest(o.Request, o.SortableFields)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := o.Filter
+	if o.FilterSchema != nil {
+		qf, err := ParseFilter(o.Request.URL.Query(), o.FilterSchema)
+		if err != nil {
+			return nil, err
+		}
+		if len(qf) > 0 {
+			filter = combineFilters(filter, qf)
+		}
+	}
+
+	defSize, minSize, maxSize := pageSizeLimits(o)
+	if pageSize == 0 {
+		pageSize, err = getPageSize(o.Request, defSize, minSize, maxSize, o.StrictPageSize)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		pageSize = clampPageSize(pageSize, minSize, maxSize)
 	}
 
 	if qt == 0 {
 		qt = time.Duration(1 * time.Minute)
 	}
 
-	return PageMaster{
-		collection:   c,
-		ctx:          r.Context(),
-		database:     d,
-		from:         from,
-		pageSize:     pageSize,
-		queryTimeout: qt,
+	maxOffset := o.MaxOffset
+	if maxOffset == 0 {
+		maxOffset = defaultMaxOffset
+	}
+
+	var page int64
+	if o.Mode == OffsetMode {
+
+		page, err = getPage(o.Request)
+		if err != nil {
+			return nil, err
+		}
+
+		if !withinMaxOffset(page, pageSize, maxOffset) {
+			return nil, errPageBeyondMaxOffset()
+		}
+
+		from, before = "", ""
+		includeTotal = true
+	}
+
+	var search string
+	if o.Mode == SearchMode {
+		search, err = getSearchFromRequest(o.Request)
+		if err != nil {
+			return nil, err
+		}
+
+		if sort != nil {
+			return nil, &RequestError{Parameter: "sort", Detail: "search results are sorted by relevance"}
+		}
+		sort = searchSort
+	}
+
+	p := &PageMaster{
+		codec:          codec,
+		collation:      o.Collation,
+		collection:     c,
+		ctx:            r.Context(),
+		database:       d,
+		fields:         fields,
+		filter:         filter,
+		hint:           o.Hint,
+		includeTotal:   includeTotal,
+		maxOffset:      maxOffset,
+		maxTime:        o.MaxTime,
+		mode:           o.Mode,
+		observer:       o.Observer,
+		page:           page,
+		pageSize:       pageSize,
+		queryTimeout:   qt,
+		readPreference: o.ReadPreference,
+		search:         search,
+		sort:           o.Sort,
+		store:          st,
+		snapshotField:  o.SnapshotField,
+		useSnapshot:    o.Snapshot,
+	}
+
+	if sort != nil {
+		p.sort = sort
+	}
+
+	if from != "" {
+		err = p.applyCursor("from", from, sort != nil)
+	}
+
+	if before != "" {
+		p.backward = true
+		err = p.applyCursor("before", before, sort != nil)
+	}
+
+	if err != nil {
+		return nil, err
 	}
+
+	return p, nil
+}
+
+// applyCursor decodes a from or before token into the PageMaster
+func (p *PageMaster) applyCursor(param, token string, sortRequested bool) error {
+	cur, err := p.Codec().Decode(token)
+	if err != nil {
+		return &RequestError{Parameter: param, Detail: param + " token is invalid or has expired", Err: err}
+	}
+
+	if cur.Search != p.search {
+		return &RequestError{Parameter: "q", Detail: "q cannot be changed while paging"}
+	}
+
+	if len(cur.Sort) > 0 {
+		if sortRequested && !reflect.DeepEqual(normalizeSort(p.sort), cur.Sort) {
+			return &RequestError{Parameter: "sort", Detail: "sort cannot be changed while paging"}
+		}
+		p.sort = cur.Sort
+	}
+
+	if len(cur.Values) != len(normalizeSort(p.sort)) {
+		err = &CursorError{Err: ErrInvalidCursor, Reason: "token does not match the sort fields"}
+		return &RequestError{Parameter: param, Detail: param + " token is invalid or has expired", Err: err}
+	}
+
+	p.from = cur.Values
+
+	if cur.Snapshot != nil {
+		p.snapshot = cur.Snapshot
+	}
+
+	return nil
 }
 
 func getFromTokenFromRequest(r *http.Request) string {
 	return r.URL.Query().Get("from")
 }
 
-func getPageSize(r *http.Request) int64 {
-	pgStr := r.URL.Query().Get("pageSize")
+func getBeforeTokenFromRequest(r *http.Request) string {
+	return r.URL.Query().Get("before")
+}
+
+func getIncludeTotal(r *http.Request) (bool, error) {
+	s := r.URL.Query().Get("includeTotal")
+	if s == "" {
+		return false, nil
+	}
 
-	if pgStr == "" {
-		pgStr = "50"
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, &RequestError{Parameter: "includeTotal", Detail: "includeTotal must be true or false", Err: err}
 	}
 
-	i, err := strconv.Atoi(pgStr)
+	return b, nil
+}
+
+func getPage(r *http.Request) (int64, error) {
+	s := r.URL.Query().Get("page")
+	if s == "" {
+		return 1, nil
+	}
 
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || i < 1 {
+		return 0, &RequestError{Parameter: "page", Detail: "page must be a positive integer", Err: err}
+	}
+
+	return i, nil
+}
+
+// pageSizeLimits returns the default, minimum and maximum page sizes
+func pageSizeLimits(o *NewOptions) (int64, int64, int64) {
+	defSize, minSize, maxSize := o.DefaultPageSize, o.MinPageSize, o.MaxPageSize
+	if maxSize <= 0 {
+		maxSize = defaultMaxPageSize
+	}
+
+	if minSize <= 0 {
+		minSize = defaultMinPageSize
+	}
+
+	if minSize > maxSize {
+		minSize = maxSize
+	}
+
+	if defSize <= 0 {
+		defSize = defaultPageSize
+	}
+
+	if defSize > maxSize {
+		defSize = maxSize
+	}
+
+	if defSize < minSize {
+		defSize = minSize
+	}
+
+	return defSize, minSize, maxSize
+}
+
+// getPageSize reads the pageSize query parameter
+func getPageSize(r *http.Request, defSize, minSize, maxSize int64, strict bool) (int64, error) {
+	s := r.URL.Query().Get("pageSize")
+	if s == "" {
+		return defSize, nil
+	}
+
+	i, err := strconv.ParseInt(s, 10, 64)
 	if err != nil {
-		i = 50
+		if strict {
+			return 0, &RequestError{Parameter: "pageSize", Detail: "pageSize must be an integer", Err: err}
+		}
+		return defSize, nil
+	}
+
+	if strict && (i < minSize || i > maxSize) {
+		return 0, &RequestError{
+			Parameter: "pageSize",
+			Detail:    "pageSize must be between " + strconv.FormatInt(minSize, 10) + " and " + strconv.FormatInt(maxSize, 10),
+		}
+	}
+
+	return clampPageSize(i, minSize, maxSize), nil
+}
+
+// clampPageSize keeps a page size within the limits
+func clampPageSize(i, minSize, maxSize int64) int64 {
+	if i > maxSize {
+		return maxSize
 	}
 
-	if i > 100 {
-		i = 100
+	if i < minSize {
+		return minSize
 	}
 
-	return int64(i)
+	return i
 }
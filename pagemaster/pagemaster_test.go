@@ -2,8 +2,11 @@ package pagemaster
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -51,7 +54,7 @@ func TestPageMaster_Collection(t *testing.T) {
 		collection   string
 		ctx          context.Context
 		database     *mongo.Database
-		from         interface{}
+		from         []interface{}
 		nextToken    string
 		pageSize     int64
 		queryTimeout time.Duration
@@ -96,7 +99,7 @@ func TestPageMaster_Database(t *testing.T) {
 		collection   string
 		ctx          context.Context
 		database     *mongo.Database
-		from         interface{}
+		from         []interface{}
 		nextToken    string
 		pageSize     int64
 		queryTimeout time.Duration
@@ -141,7 +144,7 @@ func TestPageMaster_FindPaginated(t *testing.T) {
 		collection   string
 		ctx          context.Context
 		database     *mongo.Database
-		from         interface{}
+		from         []interface{}
 		nextToken    string
 		pageSize     int64
 		queryTimeout time.Duration
@@ -196,7 +199,7 @@ func TestPageMaster_FindPaginated(t *testing.T) {
 				}
 
 				x := p.NextToken()
-				y := r[len(r)-1].(bson.D)[0].Value.(primitive.ObjectID).Hex()
+				y, _ := DefaultCursorCodec.Encode(&Cursor{Sort: defaultSort, Values: []interface{}{r[len(r)-1].(bson.D)[0].Value}})
 				if !reflect.DeepEqual(x, y) {
 					t.Errorf("PageMaster.FindPaginated() unexpected value = got %v, want %v", x, y)
 				}
@@ -210,7 +213,7 @@ func TestPageMaster_FindPaginated(t *testing.T) {
 				collection:   "testcollection",
 				database:     db,
 				pageSize:     50,
-				from:         testDocs[(len(testDocs)-1)-49].(bson.D)[0].Value.(primitive.ObjectID),
+				from:         []interface{}{testDocs[(len(testDocs)-1)-49].(bson.D)[0].Value},
 				queryTimeout: time.Duration(1 * time.Minute),
 			},
 			test: func(t *testing.T, r []interface{}, p *PageMaster) {
@@ -239,7 +242,7 @@ func TestPageMaster_FindPaginated(t *testing.T) {
 				}
 
 				x := p.NextToken()
-				y := r[len(r)-1].(bson.D)[0].Value.(primitive.ObjectID).Hex()
+				y, _ := DefaultCursorCodec.Encode(&Cursor{Sort: defaultSort, Values: []interface{}{r[len(r)-1].(bson.D)[0].Value}})
 				if !reflect.DeepEqual(x, y) {
 					t.Errorf("PageMaster.FindPaginated() unexpected value = got %v, want %v", x, y)
 				}
@@ -273,10 +276,12 @@ func TestPageMaster_GetMongoDBQueryFilter(t *testing.T) {
 		collection   string
 		ctx          context.Context
 		database     *mongo.Database
-		from         interface{}
+		from         []interface{}
 		nextToken    string
 		pageSize     int64
 		queryTimeout time.Duration
+		sort         []SortField
+		filter       interface{}
 	}
 
 	db, _ := mongoTestInit()
@@ -292,7 +297,7 @@ func TestPageMaster_GetMongoDBQueryFilter(t *testing.T) {
 			fields: fields{
 				collection: "testcollection",
 				database:   db,
-				from:       testID,
+				from:       []interface{}{testID},
 			},
 			want: bson.M{
 				"_id": bson.M{
@@ -308,6 +313,58 @@ func TestPageMaster_GetMongoDBQueryFilter(t *testing.T) {
 			},
 			want: bson.M{},
 		},
+		2: {
+			name: "ensure compound sorts produce a range over every sort field",
+			fields: fields{
+				collection: "testcollection",
+				database:   db,
+				from:       []interface{}{int64(1600000000), "b", testID},
+				sort: []SortField{
+					{Key: "createdAt", Direction: Descending},
+					{Key: "name", Direction: Ascending},
+				},
+			},
+			want: bson.M{
+				"$or": bson.A{
+					bson.M{"$or": bson.A{bson.M{"createdAt": bson.M{"$lt": int64(1600000000)}}, bson.M{"createdAt": nil}}},
+					bson.M{"createdAt": int64(1600000000), "name": bson.M{"$gt": "b"}},
+					bson.M{"createdAt": int64(1600000000), "name": "b", "_id": bson.M{"$gt": testID}},
+				},
+			},
+		},
+		3: {
+			name: "ensure a base filter that constrains _id is intersected with the cursor",
+			fields: fields{
+				collection: "testcollection",
+				database:   db,
+				from:       []interface{}{testID},
+				filter:     bson.M{"_id": bson.M{"$ne": testID}, "status": "active"},
+			},
+			want: bson.M{
+				"$and": bson.A{
+					bson.M{"_id": bson.M{"$ne": testID}, "status": "active"},
+					bson.M{"_id": bson.M{"$lt": testID}},
+				},
+			},
+		},
+		4: {
+			name: "ensure null sort values continue through the documents ordered around null",
+			fields: fields{
+				collection: "testcollection",
+				database:   db,
+				from:       []interface{}{nil, nil, testID},
+				sort: []SortField{
+					{Key: "deletedAt", Direction: Descending},
+					{Key: "name", Direction: Ascending},
+				},
+			},
+			want: bson.M{
+				"$or": bson.A{
+					bson.M{"deletedAt": nil, "name": bson.M{"$ne": nil}},
+					bson.M{"deletedAt": nil, "name": nil, "_id": bson.M{"$gt": testID}},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -319,6 +376,8 @@ func TestPageMaster_GetMongoDBQueryFilter(t *testing.T) {
 				nextToken:    tt.fields.nextToken,
 				pageSize:     tt.fields.pageSize,
 				queryTimeout: tt.fields.queryTimeout,
+				sort:         tt.fields.sort,
+				filter:       tt.fields.filter,
 			}
 			if got := p.GetMongoDBQueryFilter(); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("PageMaster.GetMongoDBQueryFilter() = %v, want %v", got, tt.want)
@@ -332,7 +391,7 @@ func TestPageMaster_NextToken(t *testing.T) {
 		collection   string
 		ctx          context.Context
 		database     *mongo.Database
-		from         interface{}
+		from         []interface{}
 		nextToken    string
 		pageSize     int64
 		queryTimeout time.Duration
@@ -389,7 +448,7 @@ func TestPageMaster_PageSize(t *testing.T) {
 		collection   string
 		ctx          context.Context
 		database     *mongo.Database
-		from         interface{}
+		from         []interface{}
 		nextToken    string
 		pageSize     int64
 		queryTimeout time.Duration
@@ -423,17 +482,167 @@ func TestNew(t *testing.T) {
 	type args struct {
 		o *NewOptions
 	}
+
+	db, _ := mongoTestInit()
+	store, _ := NewMemoryStore(nil)
+	testID := primitive.NewObjectID()
+	validToken, _ := DefaultCursorCodec.Encode(&Cursor{Values: []interface{}{testID}})
+	sortedToken, _ := DefaultCursorCodec.Encode(&Cursor{
+		Sort:   []SortField{{Key: "name", Direction: Ascending}, {Key: "_id", Direction: Ascending}},
+		Values: []interface{}{"b", testID},
+	})
+	foreignToken, _ := (&CursorCodec{Keys: [][]byte{[]byte("foreign")}}).Encode(&Cursor{Values: []interface{}{testID}})
+	get := func(url string) *http.Request {
+		r, _ := http.NewRequest("GET", url, nil)
+		return r
+	}
+
 	tests := []struct {
-		name string
-		args args
-		want PageMaster
+		name      string
+		args      args
+		wantErr   error
+		wantParam string
+		wantFrom  []interface{}
 	}{
-		// TODO: Add test cases.
+		0: {
+			name:    "it should error without a request",
+			args:    args{o: &NewOptions{Store: store}},
+			wantErr: ErrNoRequest,
+		},
+		1: {
+			name:    "it should error without a database",
+			args:    args{o: &NewOptions{Request: get("/things"), Collection: "testcollection"}},
+			wantErr: ErrNoDatabase,
+		},
+		2: {
+			name:    "it should error without a collection name",
+			args:    args{o: &NewOptions{Request: get("/things"), Database: db}},
+			wantErr: ErrNoCollection,
+		},
+		3: {
+			name:      "it should reject a from token that is not a cursor",
+			args:      args{o: &NewOptions{Request: get("/things?from=" + testID.Hex()), Store: store}},
+			wantErr:   ErrInvalidCursor,
+			wantParam: "from",
+		},
+		4: {
+			name:      "it should reject a from token signed with another key",
+			args:      args{o: &NewOptions{Request: get("/things?from=" + foreignToken), Store: store}},
+			wantErr:   ErrInvalidCursor,
+			wantParam: "from",
+		},
+		5: {
+			name:     "it should decode a valid from token",
+			args:     args{o: &NewOptions{Request: get("/things?from=" + validToken), Store: store}},
+			wantFrom: []interface{}{testID},
+		},
+		6: {
+			name:      "it should reject before combined with from",
+			args:      args{o: &NewOptions{Request: get("/things?from=" + validToken + "&before=" + validToken), Store: store}},
+			wantParam: "before",
+		},
+		7: {
+			name:      "it should reject an includeTotal that is not a boolean",
+			args:      args{o: &NewOptions{Request: get("/things?includeTotal=maybe"), Store: store}},
+			wantParam: "includeTotal",
+		},
+		8: {
+			name:      "it should reject fields outside the allowlist",
+			args:      args{o: &NewOptions{Request: get("/things?fields=name,secret"), SelectableFields: []string{"name"}, Store: store}},
+			wantParam: "fields",
+		},
+		9: {
+			name:      "it should reject sorting on fields outside the allowlist",
+			args:      args{o: &NewOptions{Request: get("/things?sort=-secret"), SortableFields: []string{"name"}, Store: store}},
+			wantParam: "sort",
+		},
+		10: {
+			name: "it should reject a sort that disagrees with the token",
+			args: args{o: &NewOptions{
+				Request:        get("/things?sort=-name&from=" + sortedToken),
+				SortableFields: []string{"name"},
+				Store:          store,
+			}},
+			wantParam: "sort",
+		},
+		11: {
+			name:     "it should continue with the token's sort",
+			args:     args{o: &NewOptions{Request: get("/things?from=" + sortedToken), Store: store}},
+			wantFrom: []interface{}{"b", testID},
+		},
+		12: {
+			name:      "it should reject a pageSize over the max in strict mode",
+			args:      args{o: &NewOptions{Request: get("/things?pageSize=101"), StrictPageSize: true, Store: store}},
+			wantParam: "pageSize",
+		},
+		13: {
+			name: "it should accept a mongodb database and collection",
+			args: args{o: &NewOptions{Request: get("/things"), Database: db, Collection: "testcollection"}},
+		},
+		14: {
+			name:    "it should error with a cursor codec that has no key",
+			args:    args{o: &NewOptions{CursorCodec: &CursorCodec{}, Request: get("/things"), Store: store}},
+			wantErr: ErrNoCursorCodec,
+		},
+		15: {
+			name:    "it should error with a snapshot outside of keyset mode",
+			args:    args{o: &NewOptions{Mode: OffsetMode, Request: get("/things"), Snapshot: true, Store: store}},
+			wantErr: ErrSnapshotMode,
+		},
+		16: {
+			name:    "it should error with an unknown mode",
+			args:    args{o: &NewOptions{Mode: Mode(9), Request: get("/things"), Store: store}},
+			wantErr: ErrInvalidMode,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := New(tt.args.o); !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("New() = %v, want %v", got, tt.want)
+			got, err := New(tt.args.o)
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) || tt.wantErr == nil && tt.wantParam == "" && err != nil {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.wantParam != "" {
+				var re *RequestError
+				if !errors.As(err, &re) {
+					t.Errorf("New() error is not a *RequestError = %T", err)
+					return
+				}
+				if re.APIError().StatusCode != 400 || re.Parameter != tt.wantParam {
+					t.Errorf("New() unexpected request error = %v, want a 400 for %v", re.APIError(), tt.wantParam)
+				}
+				return
+			}
+
+			if err == nil && !reflect.DeepEqual(got.from, tt.wantFrom) {
+				t.Errorf("New() from = %v, want %v", got.from, tt.wantFrom)
+			}
+		})
+	}
+}
+
+func TestNew_DefaultCursorCodec(t *testing.T) {
+	store, _ := NewMemoryStore(nil)
+	r, _ := http.NewRequest("GET", "/things", nil)
+
+	defer func(c *CursorCodec) { DefaultCursorCodec = c }(DefaultCursorCodec)
+	DefaultCursorCodec = nil
+
+	tests := []struct {
+		name    string
+		o       *NewOptions
+		wantErr error
+	}{
+		0: {name: "it should require a codec for cursor tokens", o: &NewOptions{Request: r, Store: store}, wantErr: ErrNoCursorCodec},
+		1: {name: "it should require a codec in search mode", o: &NewOptions{Mode: SearchMode, Request: r, Store: store}, wantErr: ErrNoCursorCodec},
+		2: {name: "it should not require a codec in offset mode", o: &NewOptions{Mode: OffsetMode, Request: r, Store: store}},
+		3: {name: "it should accept a codec option", o: &NewOptions{CursorCodec: &CursorCodec{Keys: [][]byte{[]byte("k")}}, Request: r, Store: store}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.o); !errors.Is(err, tt.wantErr) {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
@@ -461,20 +670,228 @@ func Test_getFromTokenFromRequest(t *testing.T) {
 
 func Test_getPageSize(t *testing.T) {
 	type args struct {
-		r *http.Request
+		r      *http.Request
+		strict bool
+	}
+	get := func(url string) *http.Request {
+		r, _ := http.NewRequest("GET", url, nil)
+		return r
 	}
 	tests := []struct {
-		name string
-		args args
-		want int64
+		name    string
+		args    args
+		want    int64
+		wantErr bool
 	}{
-		// TODO: Add test cases.
+		0: {name: "it should use the default without a pageSize", args: args{r: get("/things")}, want: 20},
+		1: {name: "it should use the requested pageSize", args: args{r: get("/things?pageSize=30")}, want: 30},
+		2: {name: "it should clamp a pageSize over the max", args: args{r: get("/things?pageSize=500")}, want: 40},
+		3: {name: "it should clamp a pageSize under the min", args: args{r: get("/things?pageSize=-3")}, want: 5},
+		4: {name: "it should use the default for a non-numeric pageSize", args: args{r: get("/things?pageSize=lots")}, want: 20},
+		5: {name: "it should accept a pageSize within the limits in strict mode", args: args{r: get("/things?pageSize=40"), strict: true}, want: 40},
+		6: {name: "it should use the default without a pageSize in strict mode", args: args{r: get("/things"), strict: true}, want: 20},
+		7: {name: "it should reject a pageSize over the max in strict mode", args: args{r: get("/things?pageSize=41"), strict: true}, wantErr: true},
+		8: {name: "it should reject a negative pageSize in strict mode", args: args{r: get("/things?pageSize=-1"), strict: true}, wantErr: true},
+		9: {name: "it should reject a non-numeric pageSize in strict mode", args: args{r: get("/things?pageSize=lots"), strict: true}, wantErr: true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := getPageSize(tt.args.r); got != tt.want {
+			got, err := getPageSize(tt.args.r, 20, 5, 40, tt.args.strict)
+			var re *RequestError
+			if tt.wantErr != errors.As(err, &re) {
+				t.Errorf("getPageSize() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
 				t.Errorf("getPageSize() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func Test_pageSizeLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		o       *NewOptions
+		wantDef int64
+		wantMin int64
+		wantMax int64
+	}{
+		0: {name: "it should use the package defaults", o: &NewOptions{}, wantDef: 50, wantMin: 1, wantMax: 100},
+		1: {
+			name:    "it should use the configured limits",
+			o:       &NewOptions{DefaultPageSize: 25, MinPageSize: 10, MaxPageSize: 500},
+			wantDef: 25,
+			wantMin: 10,
+			wantMax: 500,
+		},
+		2: {name: "it should keep the default under the max", o: &NewOptions{MaxPageSize: 20}, wantDef: 20, wantMin: 1, wantMax: 20},
+		3: {name: "it should keep the default over the min", o: &NewOptions{DefaultPageSize: 5, MinPageSize: 10}, wantDef: 10, wantMin: 10, wantMax: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def, min, max := pageSizeLimits(tt.o)
+			if def != tt.wantDef || min != tt.wantMin || max != tt.wantMax {
+				t.Errorf("pageSizeLimits() = %v, %v, %v, want %v, %v, %v", def, min, max, tt.wantDef, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestNew_PageSizeOption(t *testing.T) {
+	store, _ := NewMemoryStore(memoryTestDocuments(30))
+
+	tests := []struct {
+		name     string
+		o        NewOptions
+		want     int64
+		wantDocs int
+	}{
+		0: {name: "it should use the option", o: NewOptions{PageSize: 20}, want: 20, wantDocs: 20},
+		1: {name: "it should clamp the option to the max", o: NewOptions{PageSize: 500}, want: 100, wantDocs: 30},
+		2: {name: "it should clamp a negative option to the min", o: NewOptions{PageSize: -5}, want: 1, wantDocs: 1},
+		3: {name: "it should clamp the option to a configured min", o: NewOptions{MinPageSize: 10, PageSize: 3}, want: 10, wantDocs: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := http.NewRequest("GET", "/things?pageSize=7", nil)
+			o := tt.o
+			o.Request = r
+			o.Store = store
+			p, err := New(&o)
+			if err != nil {
+				t.Errorf("New() error = %v", err)
+				return
+			}
+
+			docs, err := p.FindPaginated()
+			if err != nil {
+				t.Errorf("PageMaster.FindPaginated() error = %v", err)
+				return
+			}
+			if *p.PageSize() != tt.want || len(docs) != tt.wantDocs {
+				t.Errorf("New() page size = %v with %v documents, want %v", *p.PageSize(), len(docs), tt.want)
+			}
+		})
+	}
+}
+
+func benchmarkDocuments(n int) []bson.Raw {
+	docs := make([]bson.Raw, 0, n)
+
+	for i := 0; i < n; i++ {
+		d := bson.D{
+			{Key: "_id", Value: primitive.NewObjectID()},
+			{Key: "createdAt", Value: time.Now().Unix()},
+			{Key: "rev", Value: int32(i)},
+		}
+		for f := 0; f < 50; f++ {
+			d = append(d, bson.E{Key: "field" + strconv.Itoa(f), Value: strings.Repeat("x", 64)})
+		}
+
+		b, err := bson.Marshal(d)
+		if err != nil {
+			panic(err)
+		}
+		docs = append(docs, b)
+	}
+
+	return docs
+}
+
+// BenchmarkDecodeDocuments_Twice decodes a page into a bson.D and again into a bson.M
+func BenchmarkDecodeDocuments_Twice(b *testing.B) {
+	docs := benchmarkDocuments(int(defaultMaxPageSize))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		results := make([]interface{}, 0)
+		var lastID interface{}
+		for _, doc := range docs {
+			var v bson.D
+			if err := bson.Unmarshal(doc, &v); err != nil {
+				b.Fatal(err)
+			}
+			var m bson.M
+			if err := bson.Unmarshal(doc, &m); err != nil {
+				b.Fatal(err)
+			}
+			lastID = m["_id"]
+			results = append(results, v)
+		}
+		_ = lastID
+	}
+}
+
+func BenchmarkDecodeDocuments(b *testing.B) {
+	docs := benchmarkDocuments(int(defaultMaxPageSize))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := decodeDocuments(docs); err != nil {
+			b.Fatal(err)
+		}
+		_ = sortValues(docs[len(docs)-1], defaultSort)
+	}
+}
+
+func BenchmarkDecodeAll(b *testing.B) {
+	docs := benchmarkDocuments(int(defaultMaxPageSize))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := decodeAll[memoryTestDocument](docs); err != nil {
+			b.Fatal(err)
+		}
+		_ = sortValues(docs[len(docs)-1], defaultSort)
+	}
+}
+
+func BenchmarkPageMaster_FindPaginated(b *testing.B) {
+	docs := make([]interface{}, 0)
+	for _, d := range benchmarkDocuments(1000) {
+		docs = append(docs, d)
+	}
+
+	store, err := NewMemoryStore(docs)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	r, _ := http.NewRequest("GET", "/things?pageSize=100", nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		p, err := New(&NewOptions{Request: r, Store: store})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := p.FindPaginated(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestMode_String(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Mode
+		want string
+	}{
+		0: {name: "it should name keyset mode", m: KeysetMode, want: "KeysetMode"},
+		1: {name: "it should name search mode", m: SearchMode, want: "SearchMode"},
+		2: {name: "it should not panic on an unknown mode", m: Mode(7), want: "Mode(7)"},
+		3: {name: "it should not panic on a negative mode", m: Mode(-1), want: "Mode(-1)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.String(); got != tt.want {
+				t.Errorf("Mode.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package pagemaster
+
+import (
+	"net/http"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// getFieldsFromRequest parses the comma separated fields query parameter
+func getFieldsFromRequest(r *http.Request, allowed []string) ([]string, error) {
+	s := r.URL.Query().Get("fields")
+	if s == "" {
+		return nil, nil
+	}
+
+	fields := make([]string, 0)
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if !contains(allowed, f) {
+			return nil, &RequestError{Parameter: "fields", Detail: "unknown field " + f}
+		}
+		fields = append(fields, f)
+	}
+
+	return fields, nil
+}
+
+// projectionFields adds the sort fields to the requested fields
+func projectionFields(fields []string, s []SortField) []string {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	p := make([]string, 0, len(fields)+len(s))
+	for _, f := range fields {
+		if !coveredBy(p, f) {
+			p = append(p, f)
+		}
+	}
+
+	for _, f := range s {
+		if coveredBy(p, f.Key) {
+			continue
+		}
+		kept := p[:0]
+		for _, e := range p {
+			if !strings.HasPrefix(e, f.Key+".") {
+				kept = append(kept, e)
+			}
+		}
+		p = append(kept, f.Key)
+	}
+
+	return p
+}
+
+func coveredBy(fields []string, key string) bool {
+	for _, f := range fields {
+		if f == key || strings.HasPrefix(key, f+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// projectionDocument converts field names into a mongodb projection
+func projectionDocument(fields []string) bson.D {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	d := make(bson.D, 0, len(fields))
+	for _, f := range fields {
+		d = append(d, bson.E{Key: f, Value: 1})
+	}
+
+	return d
+}
+
+// projectRaw applies an inclusion projection to a document the way mongodb does, always keeping _id
+func projectRaw(doc bson.Raw, fields []string) (bson.Raw, error) {
+	if len(fields) == 0 {
+		return doc, nil
+	}
+
+	tree := map[string]interface{}{"_id": nil}
+	for _, f := range fields {
+		node := tree
+		parts := strings.Split(f, ".")
+		for i, part := range parts {
+			if i == len(parts)-1 {
+				node[part] = nil
+				break
+			}
+			child, ok := node[part].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				node[part] = child
+			}
+			node = child
+		}
+	}
+
+	d, err := projectTree(doc, tree)
+	if err != nil {
+		return nil, err
+	}
+
+	return bson.Marshal(d)
+}
+
+func projectTree(doc bson.Raw, tree map[string]interface{}) (bson.D, error) {
+	elems, err := doc.Elements()
+	if err != nil {
+		return nil, err
+	}
+
+	d := bson.D{}
+	for _, e := range elems {
+		node, ok := tree[e.Key()]
+		if !ok {
+			continue
+		}
+
+		sub, nested := node.(map[string]interface{})
+		if !nested {
+			d = append(d, bson.E{Key: e.Key(), Value: e.Value()})
+			continue
+		}
+
+		child, isDoc := e.Value().DocumentOK()
+		if !isDoc {
+			continue
+		}
+
+		cd, err := projectTree(child, sub)
+		if err != nil {
+			return nil, err
+		}
+		d = append(d, bson.E{Key: e.Key(), Value: cd})
+	}
+
+	return d, nil
+}
@@ -0,0 +1,117 @@
+package pagemaster
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func Test_getFieldsFromRequest(t *testing.T) {
+	allowed := []string{"name", "price", "customer.name"}
+
+	tests := []struct {
+		name    string
+		url     string
+		want    []string
+		wantErr bool
+	}{
+		0: {name: "it should return nothing without the parameter", url: "/things"},
+		1: {name: "it should parse allowed fields", url: "/things?fields=name,%20price,", want: []string{"name", "price"}},
+		2: {name: "it should reject unknown fields", url: "/things?fields=name,secret", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := http.NewRequest("GET", tt.url, nil)
+			got, err := getFieldsFromRequest(r, allowed)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getFieldsFromRequest() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getFieldsFromRequest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_projectionFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []string
+		sort   []SortField
+		want   []string
+	}{
+		0: {
+			name: "it should not project without fields",
+			sort: defaultSort,
+		},
+		1: {
+			name:   "it should add the sort fields",
+			fields: []string{"name"},
+			sort:   normalizeSort([]SortField{{Key: "createdAt"}}),
+			want:   []string{"name", "createdAt", "_id"},
+		},
+		2: {
+			name:   "it should not add sort fields covered by a requested parent",
+			fields: []string{"customer", "name"},
+			sort:   normalizeSort([]SortField{{Key: "customer.name"}}),
+			want:   []string{"customer", "name", "_id"},
+		},
+		3: {
+			name:   "it should replace requested children of a sort field",
+			fields: []string{"customer.name", "price"},
+			sort:   normalizeSort([]SortField{{Key: "customer"}}),
+			want:   []string{"price", "customer", "_id"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := projectionFields(tt.fields, tt.sort); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("projectionFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_projectRaw(t *testing.T) {
+	doc, _ := bson.Marshal(bson.D{
+		{Key: "_id", Value: int32(1)},
+		{Key: "name", Value: "a"},
+		{Key: "price", Value: 2.5},
+		{Key: "customer", Value: bson.D{{Key: "name", Value: "x"}, {Key: "email", Value: "x@example.com"}}},
+	})
+
+	tests := []struct {
+		name   string
+		fields []string
+		want   bson.D
+	}{
+		0: {
+			name:   "it should keep _id and the listed fields",
+			fields: []string{"price"},
+			want:   bson.D{{Key: "_id", Value: int32(1)}, {Key: "price", Value: 2.5}},
+		},
+		1: {
+			name:   "it should project embedded fields",
+			fields: []string{"customer.name"},
+			want:   bson.D{{Key: "_id", Value: int32(1)}, {Key: "customer", Value: bson.D{{Key: "name", Value: "x"}}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := projectRaw(bson.Raw(doc), tt.fields)
+			if err != nil {
+				t.Errorf("projectRaw() error = %v", err)
+				return
+			}
+
+			var got bson.D
+			_ = bson.Unmarshal(raw, &got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("projectRaw() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package pagemaster
+
+import "errors"
+
+// Connection is a page of results in the shape of a Relay connection
+type Connection[T any] struct {
+	Edges    []Edge[T] `json:"edges"`
+	PageInfo PageInfo  `json:"pageInfo"`
+}
+
+// Edge is a single result of a Connection with the cursor that points at it
+type Edge[T any] struct {
+	Cursor string `json:"cursor"`
+	Node   T      `json:"node"`
+}
+
+// PageInfo describes the position of a Connection's page
+type PageInfo struct {
+	EndCursor       string `json:"endCursor"`
+	HasNextPage     bool   `json:"hasNextPage"`
+	HasPreviousPage bool   `json:"hasPreviousPage"`
+	StartCursor     string `json:"startCursor"`
+}
+
+// NewConnection pairs the nodes of the last page fetched with their cursors
+func NewConnection[T any](p *PageMaster, nodes []T) (*Connection[T], error) {
+	cursors, err := p.Cursors()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.mode != OffsetMode && len(cursors) != len(nodes) {
+		return nil, errors.New("pagemaster: connection nodes do not match the last page fetched")
+	}
+
+	c := &Connection[T]{
+		Edges: make([]Edge[T], 0, len(nodes)),
+		PageInfo: PageInfo{
+			HasNextPage:     p.HasNext(),
+			HasPreviousPage: p.HasPrev(),
+		},
+	}
+
+	for i, n := range nodes {
+		e := Edge[T]{Node: n}
+		if i < len(cursors) {
+			e.Cursor = cursors[i]
+		}
+		c.Edges = append(c.Edges, e)
+	}
+
+	if len(c.Edges) > 0 {
+		c.PageInfo.StartCursor = c.Edges[0].Cursor
+		c.PageInfo.EndCursor = c.Edges[len(c.Edges)-1].Cursor
+	}
+
+	return c, nil
+}
+
+// FindConnection executes a paginated query like Find and returns the results as a Relay connection
+func FindConnection[T any](p *PageMaster) (*Connection[T], error) {
+	nodes, err := Find[T](p)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewConnection(p, nodes)
+}
@@ -0,0 +1,115 @@
+package pagemaster
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestFindConnection(t *testing.T) {
+	store, err := NewMemoryStore(memoryTestDocuments(25))
+	if err != nil {
+		t.Errorf("NewMemoryStore() error = %v", err)
+		return
+	}
+
+	fetch := func(query string, mode Mode) (*PageMaster, *Connection[memoryTestDocument]) {
+		r, _ := http.NewRequest("GET", "/things?pageSize=10"+query, nil)
+		p, err := New(&NewOptions{Request: r, Mode: mode, Store: store})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		c, err := FindConnection[memoryTestDocument](p)
+		if err != nil {
+			t.Fatalf("FindConnection() error = %v", err)
+		}
+		return p, c
+	}
+
+	revs := func(c *Connection[memoryTestDocument]) []int {
+		r := make([]int, 0)
+		for _, e := range c.Edges {
+			r = append(r, e.Node.Rev)
+		}
+		return r
+	}
+
+	first, firstConn := fetch("", KeysetMode)
+	_, afterConn := fetch("&from="+firstConn.Edges[4].Cursor, KeysetMode)
+	_, beforeConn := fetch("&before="+afterConn.Edges[2].Cursor, KeysetMode)
+	_, offsetConn := fetch("&page=2", OffsetMode)
+
+	distinct := map[string]bool{}
+	for _, e := range firstConn.Edges {
+		distinct[e.Cursor] = true
+	}
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		0:  {name: "it should return an edge for every result", got: revs(firstConn), want: []int{24, 23, 22, 21, 20, 19, 18, 17, 16, 15}},
+		1:  {name: "it should give every edge its own cursor", got: len(distinct), want: 10},
+		2:  {name: "the start cursor should be the first edge's cursor", got: firstConn.PageInfo.StartCursor, want: firstConn.Edges[0].Cursor},
+		3:  {name: "the end cursor should continue like the next token", got: firstConn.PageInfo.EndCursor, want: first.NextToken()},
+		4:  {name: "the first page should have a next page", got: firstConn.PageInfo.HasNextPage, want: true},
+		5:  {name: "the first page should not have a previous page", got: firstConn.PageInfo.HasPreviousPage, want: false},
+		6:  {name: "an edge cursor should continue after its node", got: revs(afterConn), want: []int{19, 18, 17, 16, 15, 14, 13, 12, 11, 10}},
+		7:  {name: "a page after an edge should have a previous page", got: afterConn.PageInfo.HasPreviousPage, want: true},
+		8:  {name: "an edge cursor should page back from its node", got: revs(beforeConn), want: []int{24, 23, 22, 21, 20, 19, 18}},
+		9:  {name: "offset pages should have edges", got: revs(offsetConn), want: []int{14, 13, 12, 11, 10, 9, 8, 7, 6, 5}},
+		10: {name: "offset pages should not have cursors", got: offsetConn.PageInfo.EndCursor, want: ""},
+		11: {name: "offset pages should have a previous page", got: offsetConn.PageInfo.HasPreviousPage, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewConnection(t *testing.T) {
+	store, err := NewMemoryStore(memoryTestDocuments(5))
+	if err != nil {
+		t.Errorf("NewMemoryStore() error = %v", err)
+		return
+	}
+
+	r, _ := http.NewRequest("GET", "/things?pageSize=10", nil)
+	p, err := New(&NewOptions{Request: r, Store: store})
+	if err != nil {
+		t.Errorf("New() error = %v", err)
+		return
+	}
+
+	nodes, err := p.FindPaginated()
+	if err != nil {
+		t.Errorf("PageMaster.FindPaginated() error = %v", err)
+		return
+	}
+
+	tests := []struct {
+		name    string
+		nodes   []interface{}
+		wantLen int
+		wantErr bool
+	}{
+		0: {name: "it should pair the page's nodes with cursors", nodes: nodes, wantLen: 5},
+		1: {name: "it should reject nodes that are not the page's results", nodes: nodes[1:], wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewConnection(p, tt.nodes)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewConnection() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && len(got.Edges) != tt.wantLen {
+				t.Errorf("NewConnection() edges = %v, want %v", len(got.Edges), tt.wantLen)
+			}
+		})
+	}
+}
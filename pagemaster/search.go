@@ -0,0 +1,32 @@
+package pagemaster
+
+import (
+	"net/http"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// SearchScoreField is the field search results carry their text score in
+const SearchScoreField = "score"
+
+var searchSort = []SortField{{Key: SearchScoreField, Direction: Descending}}
+
+// searchPipeline matches the search text and exposes the text score as a field
+func (p *PageMaster) searchPipeline() mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{{Key: "$text", Value: bson.D{{Key: "$search", Value: p.search}}}}}},
+		{{Key: "$addFields", Value: bson.D{{Key: SearchScoreField, Value: bson.D{{Key: "$meta", Value: "textScore"}}}}}},
+		PaginationStage(),
+	}
+}
+
+func getSearchFromRequest(r *http.Request) (string, error) {
+	s := strings.TrimSpace(r.URL.Query().Get("q"))
+	if s == "" {
+		return "", &RequestError{Parameter: "q", Detail: "q is required to search"}
+	}
+
+	return s, nil
+}
@@ -0,0 +1,158 @@
+package pagemaster
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type searchTestRow struct {
+	ID    int32   `bson:"_id"`
+	Score float64 `bson:"score"`
+}
+
+// searchTestStore returns documents carrying the score the $addFields stage would add
+func searchTestStore(t *testing.T) *testAggregator {
+	docs := make([]interface{}, 0)
+	for i := int32(0); i < 12; i++ {
+		docs = append(docs, bson.D{{Key: "_id", Value: i}, {Key: "score", Value: float64(i%4) + 0.5}})
+	}
+
+	memory, err := NewMemoryStore(docs)
+	if err != nil {
+		t.Fatalf("NewMemoryStore() error = %v", err)
+	}
+
+	return &testAggregator{MemoryStore: memory}
+}
+
+func TestPageMaster_FindPaginated_Search(t *testing.T) {
+	store := searchTestStore(t)
+
+	fetch := func(query string) (*PageMaster, []searchTestRow) {
+		r, _ := http.NewRequest("GET", "/things?pageSize=5&q=coffee"+query, nil)
+		p, err := New(&NewOptions{Mode: SearchMode, Request: r, Store: store})
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		got, err := Find[searchTestRow](p)
+		if err != nil {
+			t.Fatalf("Find() error = %v", err)
+		}
+		return p, got
+	}
+
+	first, firstPage := fetch("")
+	pipeline := store.pipeline
+	second, secondPage := fetch("&from=" + first.NextToken())
+	last, lastPage := fetch("&from=" + second.NextToken())
+	_, backPage := fetch("&before=" + second.PrevToken())
+
+	cur, err := first.Codec().Decode(first.NextToken())
+	if err != nil {
+		t.Fatalf("CursorCodec.Decode() error = %v", err)
+	}
+
+	ids := func(pages ...[]searchTestRow) []int32 {
+		s := make([]int32, 0)
+		for _, page := range pages {
+			for _, row := range page {
+				s = append(s, row.ID)
+			}
+		}
+		return s
+	}
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		0: {name: "the pipeline should start with the text search", got: pipeline[0], want: bson.D{{Key: "$match", Value: bson.D{{Key: "$text", Value: bson.D{{Key: "$search", Value: "coffee"}}}}}}},
+		1: {name: "the pipeline should expose the text score", got: pipeline[1], want: bson.D{{Key: "$addFields", Value: bson.D{{Key: "score", Value: bson.D{{Key: "$meta", Value: "textScore"}}}}}}},
+		2: {name: "the pipeline should sort by score", got: pipeline[2], want: bson.D{{Key: "$sort", Value: bson.D{{Key: "score", Value: -1}, {Key: "_id", Value: -1}}}}},
+		3: {name: "pages should be ordered by score then _id", got: ids(firstPage, secondPage, lastPage), want: []int32{11, 7, 3, 10, 6, 2, 9, 5, 1, 8, 4, 0}},
+		4: {name: "the token should hold the score and _id", got: cur.Values, want: []interface{}{2.5, int32(6)}},
+		5: {name: "the token should pin the search text", got: cur.Search, want: "coffee"},
+		6: {name: "the last page should not have a next token", got: last.NextToken(), want: ""},
+		7: {name: "paging back should return the first page", got: backPage, want: firstPage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew_Search(t *testing.T) {
+	store := searchTestStore(t)
+
+	r, _ := http.NewRequest("GET", "/things?pageSize=5&q=coffee", nil)
+	p, err := New(&NewOptions{Mode: SearchMode, Request: r, Store: store})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err = p.FindPaginated(); err != nil {
+		t.Fatalf("PageMaster.FindPaginated() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		wantParam string
+	}{
+		0: {name: "it should require q", query: "/things", wantParam: "q"},
+		1: {name: "it should reject a blank q", query: "/things?q=%20", wantParam: "q"},
+		2: {name: "it should reject a sort parameter", query: "/things?q=coffee&sort=name", wantParam: "sort"},
+		3: {name: "it should reject a changed q while paging", query: "/things?q=tea&from=" + p.NextToken(), wantParam: "q"},
+		4: {name: "it should reject a keyset token", query: "/things?q=coffee&from=" + keysetTestToken(t, store), wantParam: "q"},
+		5: {name: "it should continue a search with the same q", query: "/things?q=coffee&from=" + p.NextToken()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := http.NewRequest("GET", tt.query, nil)
+			_, err := New(&NewOptions{Mode: SearchMode, Request: r, Store: store})
+
+			var reqErr *RequestError
+			if errors.As(err, &reqErr) != (tt.wantParam != "") {
+				t.Errorf("New() error = %v, wantParam %v", err, tt.wantParam)
+				return
+			}
+			if reqErr != nil && reqErr.Parameter != tt.wantParam {
+				t.Errorf("New() error parameter = %v, want %v", reqErr.Parameter, tt.wantParam)
+			}
+		})
+	}
+}
+
+func TestPageMaster_FindPaginated_SearchUnsupportedStore(t *testing.T) {
+	store := searchTestStore(t)
+
+	r, _ := http.NewRequest("GET", "/things?q=coffee", nil)
+	p, err := New(&NewOptions{Mode: SearchMode, Request: r, Store: store.MemoryStore})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if _, err = p.FindPaginated(); err == nil {
+		t.Errorf("PageMaster.FindPaginated() error = %v, wantErr true", err)
+	}
+}
+
+func keysetTestToken(t *testing.T, store Store) string {
+	r, _ := http.NewRequest("GET", "/things?pageSize=5", nil)
+	p, err := New(&NewOptions{Request: r, Store: store})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err = p.FindPaginated(); err != nil {
+		t.Fatalf("PageMaster.FindPaginated() error = %v", err)
+	}
+
+	return p.NextToken()
+}
@@ -0,0 +1,60 @@
+package pagemaster
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// SnapshotBound is the upper bound pinned into cursor tokens by snapshot mode
+type SnapshotBound struct {
+	Key   string      `bson:"k"`
+	Value interface{} `bson:"v"`
+}
+
+// pinSnapshot records the largest snapshot field value among the matching documents
+func (p *PageMaster) pinSnapshot(ctx context.Context) error {
+	key := p.snapshotField
+	if key == "" {
+		key = "_id"
+	}
+
+	docs, err := p.observeFind(QuerySnapshot, p.Store().Find, nil)(ctx, &Query{
+		Fields: []string{key},
+		Filter: p.filter,
+		Limit:  1,
+		Sort:   []SortField{{Key: key, Direction: Descending}},
+	})
+	if err != nil || len(docs) == 0 {
+		return err
+	}
+
+	v := lookupField(docs[0], key)
+	if p.snapshotField == "" && !isIncreasingKey(v.Type) {
+		return ErrSnapshotKey
+	}
+
+	p.snapshot = &SnapshotBound{Key: key, Value: v}
+
+	return nil
+}
+
+// isIncreasingKey reports whether an _id of type t can be expected to grow with insertion
+func isIncreasingKey(t bsontype.Type) bool {
+	switch t {
+	case bsontype.ObjectID, bsontype.Int32, bsontype.Int64, bsontype.Double, bsontype.Decimal128, bsontype.DateTime:
+		return true
+	}
+
+	return false
+}
+
+// queryFilter is the base filter restricted to the snapshot bound when one is pinned
+func (p *PageMaster) queryFilter() interface{} {
+	if p.snapshot == nil {
+		return p.filter
+	}
+
+	return combineFilters(p.filter, bson.M{p.snapshot.Key: bson.M{"$lte": p.snapshot.Value}})
+}
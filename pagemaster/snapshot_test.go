@@ -0,0 +1,170 @@
+package pagemaster
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type snapshotTestRow struct {
+	Rev int `bson:"rev"`
+}
+
+func snapshotTestDocument(id int32, updatedAt int64) bson.Raw {
+	b, _ := bson.Marshal(bson.D{{Key: "_id", Value: id}, {Key: "rev", Value: id}, {Key: "updatedAt", Value: updatedAt}})
+	return b
+}
+
+// TestPageMaster_FindPaginated_Snapshot checks which changes a snapshot leaves out
+func TestPageMaster_FindPaginated_Snapshot(t *testing.T) {
+	byID := []SortField{{Key: "_id", Direction: Ascending}}
+	byUpdatedAt := []SortField{{Key: "updatedAt", Direction: Ascending}}
+
+	insert := func(s *MemoryStore) {
+		s.documents = append(s.documents, snapshotTestDocument(100, 100))
+	}
+	touch := func(s *MemoryStore) {
+		s.documents[3] = snapshotTestDocument(3, 100)
+		s.documents[15] = snapshotTestDocument(15, 101)
+	}
+
+	seq := func(from, to int) []int {
+		s := make([]int, 0)
+		for i := from; i < to; i++ {
+			s = append(s, i)
+		}
+		return s
+	}
+
+	tests := []struct {
+		name      string
+		options   NewOptions
+		mutate    func(s *MemoryStore)
+		want      []int
+		wantTotal int64
+	}{
+		0: {
+			name:      "without a snapshot, documents inserted mid-scroll appear on later pages",
+			options:   NewOptions{Sort: byID},
+			mutate:    insert,
+			want:      append(seq(0, 25), 100),
+			wantTotal: 26,
+		},
+		1: {
+			name:      "with a snapshot, documents inserted mid-scroll never appear",
+			options:   NewOptions{Snapshot: true, Sort: byID},
+			mutate:    insert,
+			want:      seq(0, 25),
+			wantTotal: 25,
+		},
+		2: {
+			name:      "without a snapshot, documents updated mid-scroll can be seen twice",
+			options:   NewOptions{Sort: byUpdatedAt},
+			mutate:    touch,
+			want:      append(append(seq(0, 15), seq(16, 25)...), 3, 15),
+			wantTotal: 25,
+		},
+		3: {
+			name:      "with a snapshot, documents updated mid-scroll can still be seen twice",
+			options:   NewOptions{Snapshot: true, Sort: byUpdatedAt},
+			mutate:    touch,
+			want:      append(append(seq(0, 15), seq(16, 25)...), 3, 15),
+			wantTotal: 25,
+		},
+		4: {
+			name:      "with a snapshot field, documents inserted mid-scroll never appear",
+			options:   NewOptions{Snapshot: true, SnapshotField: "updatedAt", Sort: byID},
+			mutate:    insert,
+			want:      seq(0, 25),
+			wantTotal: 25,
+		},
+		5: {
+			name:      "with a snapshot field, documents updated mid-scroll are left out instead of seen twice",
+			options:   NewOptions{Snapshot: true, SnapshotField: "updatedAt", Sort: byUpdatedAt},
+			mutate:    touch,
+			want:      append(seq(0, 15), seq(16, 25)...),
+			wantTotal: 23,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &MemoryStore{}
+			for i := int32(0); i < 25; i++ {
+				store.documents = append(store.documents, snapshotTestDocument(i, int64(i)))
+			}
+
+			got := make([]int, 0)
+			var total int64
+			token := ""
+			for page := 1; page <= 10; page++ {
+				r, _ := http.NewRequest("GET", "/things?pageSize=10&includeTotal=true&from="+token, nil)
+				o := tt.options
+				o.Request = r
+				o.Store = store
+				p, err := New(&o)
+				if err != nil {
+					t.Errorf("New() error = %v", err)
+					return
+				}
+
+				docs, err := Find[snapshotTestRow](p)
+				if err != nil {
+					t.Errorf("Find() error = %v", err)
+					return
+				}
+				for _, d := range docs {
+					got = append(got, d.Rev)
+				}
+				total, _ = p.Total()
+
+				if page == 1 {
+					tt.mutate(store)
+				}
+
+				token = p.NextToken()
+				if !p.HasNext() {
+					break
+				}
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Find() revs = %v, want %v", got, tt.want)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("PageMaster.Total() = %v, want %v", total, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestPageMaster_FindPaginated_SnapshotKey(t *testing.T) {
+	store, _ := NewMemoryStore([]interface{}{
+		bson.D{{Key: "_id", Value: "b"}, {Key: "updatedAt", Value: int64(1)}},
+		bson.D{{Key: "_id", Value: "a"}, {Key: "updatedAt", Value: int64(2)}},
+	})
+
+	tests := []struct {
+		name    string
+		field   string
+		wantErr error
+	}{
+		0: {name: "it should reject a snapshot on string keys", wantErr: ErrSnapshotKey},
+		1: {name: "it should pin a snapshot field on string keys", field: "updatedAt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := http.NewRequest("GET", "/things", nil)
+			p, err := New(&NewOptions{Request: r, Snapshot: true, SnapshotField: tt.field, Store: store})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			if _, err = p.FindPaginated(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("PageMaster.FindPaginated() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
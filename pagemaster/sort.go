@@ -0,0 +1,185 @@
+package pagemaster
+
+import (
+	"net/http"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Ascending and Descending are the directions a SortField can be ordered in
+const (
+	Ascending  = 1
+	Descending = -1
+)
+
+// SortField describes a single field that paginated results are ordered by
+type SortField struct {
+	Key       string `bson:"k"`
+	Direction int    `bson:"d"`
+}
+
+var defaultSort = []SortField{{Key: "_id", Direction: Descending}}
+
+// getSortFromRequest parses a sort query parameter such as "-createdAt,name"
+func getSortFromRequest(r *http.Request, allowed []string) ([]SortField, error) {
+	s := r.URL.Query().Get("sort")
+	if s == "" {
+		return nil, nil
+	}
+
+	fields := make([]SortField, 0)
+	for _, k := range strings.Split(s, ",") {
+		k = strings.TrimSpace(k)
+		d := Ascending
+		switch {
+		case strings.HasPrefix(k, "-"):
+			k, d = k[1:], Descending
+		case strings.HasPrefix(k, "+"):
+			k = k[1:]
+		}
+
+		if k == "" {
+			continue
+		}
+
+		if !contains(allowed, k) {
+			return nil, &RequestError{Parameter: "sort", Detail: "cannot sort by " + k}
+		}
+
+		for _, f := range fields {
+			if f.Key == k {
+				return nil, &RequestError{Parameter: "sort", Detail: "cannot sort by " + k + " more than once"}
+			}
+		}
+
+		fields = append(fields, SortField{Key: k, Direction: d})
+	}
+
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	return fields, nil
+}
+
+// normalizeSort fills in the default sort and appends an _id tie-breaker
+func normalizeSort(s []SortField) []SortField {
+	if len(s) == 0 {
+		return defaultSort
+	}
+
+	n := make([]SortField, 0, len(s)+1)
+	for _, f := range s {
+		if f.Direction != Ascending {
+			f.Direction = Descending
+		}
+		n = append(n, f)
+		if f.Key == "_id" {
+			return n
+		}
+	}
+
+	return append(n, SortField{Key: "_id", Direction: n[len(n)-1].Direction})
+}
+
+// reverseSort flips the direction of every sort field
+func reverseSort(s []SortField) []SortField {
+	r := make([]SortField, len(s))
+	for i, f := range s {
+		r[i] = SortField{Key: f.Key, Direction: -f.Direction}
+	}
+
+	return r
+}
+
+// sortDocument converts sort fields into a mongodb sort specification
+func sortDocument(s []SortField) bson.D {
+	d := make(bson.D, 0, len(s))
+	for _, f := range s {
+		d = append(d, bson.E{Key: f.Key, Value: f.Direction})
+	}
+
+	return d
+}
+
+// keysetFilter builds a range filter matching documents ordered after the passed in sort values
+func keysetFilter(s []SortField, after []interface{}) bson.M {
+	if len(after) == 0 {
+		return bson.M{}
+	}
+
+	clauses := make(bson.A, 0, len(s))
+	for i, f := range s {
+		c := bson.M{}
+		for j := 0; j < i; j++ {
+			c[s[j].Key] = after[j]
+		}
+
+		switch {
+		case isNullValue(after[i]) && f.Direction == Ascending:
+			c[f.Key] = bson.M{"$ne": nil}
+		case isNullValue(after[i]):
+			continue
+		case f.Direction == Ascending:
+			c[f.Key] = bson.M{"$gt": after[i]}
+		case f.Key == "_id":
+			c[f.Key] = bson.M{"$lt": after[i]}
+		default:
+			c["$or"] = bson.A{bson.M{f.Key: bson.M{"$lt": after[i]}}, bson.M{f.Key: nil}}
+		}
+		clauses = append(clauses, c)
+	}
+
+	switch len(clauses) {
+	case 0:
+		return bson.M{"_id": bson.M{"$exists": false}}
+	case 1:
+		return clauses[0].(bson.M)
+	}
+
+	return bson.M{"$or": clauses}
+}
+
+// sortValues reads the values of the sort fields from a document. Missing fields are stored as null
+func sortValues(doc bson.Raw, s []SortField) []interface{} {
+	v := make([]interface{}, 0, len(s))
+	for _, f := range s {
+		rv := lookupField(doc, f.Key)
+		if rv.Type == 0 {
+			rv = bson.RawValue{Type: bsontype.Null}
+		}
+		v = append(v, rv)
+	}
+
+	return v
+}
+
+// isNullValue reports whether a sort value is null. Null values decode from cursor tokens as nil
+func isNullValue(v interface{}) bool {
+	switch v := v.(type) {
+	case nil, primitive.Null:
+		return true
+	case bson.RawValue:
+		return v.Type == 0 || v.Type == bsontype.Null || v.Type == bsontype.Undefined
+	}
+
+	return false
+}
+
+// compareSort orders two sets of sort values according to the sort fields
+func compareSort(a, b []bson.RawValue, s []SortField) int {
+	for i, f := range s {
+		if c := compareValues(a[i], b[i]) * f.Direction; c != 0 {
+			return c
+		}
+	}
+
+	return 0
+}
+
+func lookupField(doc bson.Raw, key string) bson.RawValue {
+	return doc.Lookup(strings.Split(key, ".")...)
+}
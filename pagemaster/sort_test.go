@@ -0,0 +1,133 @@
+package pagemaster
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func Test_normalizeSort(t *testing.T) {
+	tests := []struct {
+		name string
+		s    []SortField
+		want []SortField
+	}{
+		0: {
+			name: "it should default to descending _id",
+			want: []SortField{{Key: "_id", Direction: Descending}},
+		},
+		1: {
+			name: "it should append an _id tie-breaker in the direction of the last field",
+			s:    []SortField{{Key: "createdAt", Direction: Descending}, {Key: "name", Direction: Ascending}},
+			want: []SortField{
+				{Key: "createdAt", Direction: Descending},
+				{Key: "name", Direction: Ascending},
+				{Key: "_id", Direction: Ascending},
+			},
+		},
+		2: {
+			name: "it should not add a tie-breaker when _id is already sorted on",
+			s:    []SortField{{Key: "_id", Direction: Ascending}, {Key: "name", Direction: Ascending}},
+			want: []SortField{{Key: "_id", Direction: Ascending}},
+		},
+		3: {
+			name: "it should treat unknown directions as descending",
+			s:    []SortField{{Key: "price"}},
+			want: []SortField{{Key: "price", Direction: Descending}, {Key: "_id", Direction: Descending}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeSort(tt.s); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalizeSort() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_getSortFromRequest(t *testing.T) {
+	allowed := []string{"createdAt", "name", "price"}
+
+	tests := []struct {
+		name    string
+		url     string
+		want    []SortField
+		wantErr bool
+	}{
+		0: {name: "it should return nothing without the parameter", url: "/things"},
+		1: {
+			name: "it should parse directions",
+			url:  "/things?sort=-createdAt,name,%2Bprice",
+			want: []SortField{
+				{Key: "createdAt", Direction: Descending},
+				{Key: "name", Direction: Ascending},
+				{Key: "price", Direction: Ascending},
+			},
+		},
+		2: {name: "it should reject fields outside the allowlist", url: "/things?sort=-secret", wantErr: true},
+		3: {name: "it should reject repeated fields", url: "/things?sort=name,-name", wantErr: true},
+		4: {name: "it should ignore empty segments", url: "/things?sort=,", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := http.NewRequest("GET", tt.url, nil)
+			got, err := getSortFromRequest(r, allowed)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getSortFromRequest() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getSortFromRequest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test_keysetFilter checks that the range filter agrees with the MemoryStore ordering
+func Test_keysetFilter(t *testing.T) {
+	docs := make([]bson.Raw, 0)
+	for i := int32(0); i < 12; i++ {
+		d := bson.D{{Key: "_id", Value: i}}
+		switch i % 3 {
+		case 0:
+			d = append(d, bson.E{Key: "name", Value: nil})
+		case 1:
+			d = append(d, bson.E{Key: "name", Value: string(rune('a' + i%2))})
+		}
+		b, _ := bson.Marshal(d)
+		docs = append(docs, b)
+	}
+
+	tests := []struct {
+		name string
+		sort []SortField
+	}{
+		0: {name: "it should order null first ascending", sort: []SortField{{Key: "name", Direction: Ascending}}},
+		1: {name: "it should order null last descending", sort: []SortField{{Key: "name", Direction: Descending}}},
+		2: {name: "it should order null within mixed directions", sort: []SortField{{Key: "name", Direction: Descending}, {Key: "_id", Direction: Ascending}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := normalizeSort(tt.sort)
+			for _, from := range docs {
+				after, err := rawValues(sortValues(from, order))
+				if err != nil {
+					t.Fatalf("rawValues() error = %v", err)
+				}
+				filter := keysetFilter(order, sortValues(from, order))
+
+				for _, d := range docs {
+					got, err := matchFilter(d, filter)
+					if err != nil {
+						t.Fatalf("matchFilter() error = %v", err)
+					}
+					if want := compareSort(rawSortValues(d, order), after, order) > 0; got != want {
+						t.Errorf("keysetFilter(%v) matched %v = %v, want %v", filter, d, got, want)
+					}
+				}
+			}
+		})
+	}
+}
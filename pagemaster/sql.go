@@ -0,0 +1,605 @@
+package pagemaster
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Dialect selects the placeholder and identifier quoting style of a SQL database
+type Dialect int
+
+// Supported SQL dialects
+const (
+	PostgresDialect Dialect = iota
+	MySQLDialect
+	SQLiteDialect
+)
+
+func (d Dialect) String() string {
+	switch d {
+	case PostgresDialect:
+		return "PostgresDialect"
+	case MySQLDialect:
+		return "MySQLDialect"
+	case SQLiteDialect:
+		return "SQLiteDialect"
+	default:
+		return "Dialect(" + strconv.Itoa(int(d)) + ")"
+	}
+}
+
+// SQLStore is a Store backed by a table in a database/sql database
+type SQLStore struct {
+	DB        *sql.DB
+	Dialect   Dialect
+	KeyColumn string
+	Table     string
+}
+
+// NewSQLStore instantiates a new SQLStore for a table whose primary key column is id
+func NewSQLStore(db *sql.DB, table string, d Dialect) *SQLStore {
+	return &SQLStore{DB: db, Dialect: d, KeyColumn: "id", Table: table}
+}
+
+// Find executes a keyset paginated SELECT against the table
+func (s *SQLStore) Find(ctx context.Context, q *Query) ([]bson.Raw, error) {
+	query, args, err := s.buildQuery(q)
+	if err != nil {
+		return make([]bson.Raw, 0), err
+	}
+
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return make([]bson.Raw, 0), err
+	}
+	defer rows.Close()
+
+	return s.readRows(rows, q)
+}
+
+// Count returns the number of rows in the table matching the filter
+func (s *SQLStore) Count(ctx context.Context, filter interface{}) (int64, error) {
+	b := &sqlBuilder{store: s}
+
+	where, err := b.where(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	query := "SELECT COUNT(*) FROM " + s.Table
+	if where != "" {
+		query += " WHERE " + where
+	}
+
+	var n int64
+	err = s.DB.QueryRowContext(ctx, query, b.args...).Scan(&n)
+
+	return n, err
+}
+
+// EstimatedCount returns the number of rows in the table
+func (s *SQLStore) EstimatedCount(ctx context.Context) (int64, error) {
+	return s.Count(ctx, nil)
+}
+
+// buildQuery translates a query into a parameterized SELECT
+func (s *SQLStore) buildQuery(q *Query) (string, []interface{}, error) {
+	b := &sqlBuilder{store: s}
+	sort := normalizeSort(q.Sort)
+
+	conds := make([]string, 0, 2)
+
+	where, err := b.where(q.Filter)
+	if err != nil {
+		return "", nil, err
+	}
+	if where != "" {
+		conds = append(conds, where)
+	}
+
+	keyset, err := b.keyset(sort, q.After)
+	if err != nil {
+		return "", nil, err
+	}
+	if keyset != "" {
+		conds = append(conds, keyset)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("SELECT " + b.columns(q.Fields) + " FROM " + s.Table)
+
+	if len(conds) > 0 {
+		sb.WriteString(" WHERE " + strings.Join(conds, " AND "))
+	}
+
+	order := make([]string, 0, len(sort))
+	for _, f := range sort {
+		order = append(order, b.ident(f.Key)+b.direction(f))
+	}
+	sb.WriteString(" ORDER BY " + strings.Join(order, ", "))
+
+	if q.Limit > 0 {
+		sb.WriteString(" LIMIT " + strconv.FormatInt(q.Limit, 10))
+	}
+
+	if q.Skip > 0 {
+		sb.WriteString(" OFFSET " + strconv.FormatInt(q.Skip, 10))
+	}
+
+	return sb.String(), b.args, nil
+}
+
+// readRows converts each row into a document keyed by column name
+func (s *SQLStore) readRows(rows *sql.Rows, q *Query) ([]bson.Raw, error) {
+	size := q.Limit
+	if size < 0 {
+		size = 0
+	}
+
+	results := make([]bson.Raw, 0, size)
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return results, err
+	}
+
+	values := make([]interface{}, len(cols))
+	ptrs := make([]interface{}, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	for rows.Next() {
+		err = rows.Scan(ptrs...)
+		if err != nil {
+			return results, err
+		}
+
+		d := make(bson.D, 0, len(cols))
+		for i, c := range cols {
+			if c == s.keyColumn() {
+				c = "_id"
+			}
+			d = append(d, bson.E{Key: c, Value: sqlDocumentValue(values[i])})
+		}
+
+		doc, err := bson.Marshal(d)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, doc)
+	}
+
+	return results, rows.Err()
+}
+
+func (s *SQLStore) keyColumn() string {
+	if s.KeyColumn == "" {
+		return "id"
+	}
+
+	return s.KeyColumn
+}
+
+// sqlDocumentValue converts a scanned column into a value that can be stored in a document
+func sqlDocumentValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case []byte:
+		if utf8.Valid(v) {
+			return string(v)
+		}
+		return primitive.Binary{Data: append([]byte(nil), v...)}
+	}
+
+	return v
+}
+
+// sqlBuilder accumulates the arguments of a query while its clauses are written
+type sqlBuilder struct {
+	args  []interface{}
+	store *SQLStore
+}
+
+func (b *sqlBuilder) arg(v interface{}) string {
+	b.args = append(b.args, v)
+	if b.store.Dialect == PostgresDialect {
+		return "$" + strconv.Itoa(len(b.args))
+	}
+
+	return "?"
+}
+
+// ident maps a document key onto a quoted column name
+func (b *sqlBuilder) ident(key string) string {
+	if key == "_id" {
+		key = b.store.keyColumn()
+	}
+
+	if b.store.Dialect == MySQLDialect {
+		return "`" + strings.ReplaceAll(key, "`", "``") + "`"
+	}
+
+	return `"` + strings.ReplaceAll(key, `"`, `""`) + `"`
+}
+
+func (b *sqlBuilder) columns(fields []string) string {
+	if len(fields) == 0 {
+		return "*"
+	}
+
+	cols := []string{b.ident("_id")}
+	for _, f := range fields {
+		if f != "_id" {
+			cols = append(cols, b.ident(f))
+		}
+	}
+
+	return strings.Join(cols, ", ")
+}
+
+func (b *sqlBuilder) keyset(sort []SortField, after []interface{}) (string, error) {
+	if len(after) == 0 {
+		return "", nil
+	}
+
+	values, err := rawValues(after)
+	if err != nil {
+		return "", err
+	}
+
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		args[i], err = sqlArg(v)
+		if err != nil {
+			return "", err
+		}
+		if s, ok := args[i].(string); ok {
+			args[i] = sqlCursorTime(s)
+		}
+	}
+
+	mixed, nulls := false, false
+	for i, f := range sort {
+		if f.Direction != sort[0].Direction {
+			mixed = true
+		}
+		if args[i] == nil {
+			nulls = true
+		}
+	}
+
+	if !mixed && !nulls {
+		return b.rowKeyset(sort, args), nil
+	}
+
+	ors := make([]string, 0, len(sort))
+	for i, f := range sort {
+		if args[i] == nil && f.Direction == Descending {
+			continue
+		}
+
+		ands := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, b.equal(sort[j].Key, args[j]))
+		}
+		ands = append(ands, b.after(f, args[i]))
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+
+	if len(ors) == 0 {
+		return "1 = 0", nil
+	}
+
+	return "(" + strings.Join(ors, " OR ") + ")", nil
+}
+
+// rowKeyset compares row values when every sort field has the same direction
+func (b *sqlBuilder) rowKeyset(sort []SortField, args []interface{}) string {
+	op := sqlKeysetOperator(sort[0].Direction)
+
+	var cond string
+	if len(sort) == 1 {
+		cond = b.ident(sort[0].Key) + " " + op + " " + b.arg(args[0])
+	} else {
+		cols := make([]string, 0, len(sort))
+		params := make([]string, 0, len(sort))
+		for i, f := range sort {
+			cols = append(cols, b.ident(f.Key))
+			params = append(params, b.arg(args[i]))
+		}
+		cond = "(" + strings.Join(cols, ", ") + ") " + op + " (" + strings.Join(params, ", ") + ")"
+	}
+
+	if sort[0].Direction == Ascending {
+		return cond
+	}
+
+	ors := []string{cond}
+	for i, f := range sort {
+		if f.Key == "_id" {
+			continue
+		}
+
+		ands := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, b.equal(sort[j].Key, args[j]))
+		}
+		ands = append(ands, b.ident(f.Key)+" IS NULL")
+		ors = append(ors, strings.Join(ands, " AND "))
+	}
+
+	if len(ors) == 1 {
+		return cond
+	}
+
+	return "(" + strings.Join(ors, " OR ") + ")"
+}
+
+// after matches the rows ordered strictly after a bound on one sort field
+func (b *sqlBuilder) after(f SortField, v interface{}) string {
+	col := b.ident(f.Key)
+
+	switch {
+	case v == nil:
+		return col + " IS NOT NULL"
+	case f.Direction == Ascending:
+		return col + " > " + b.arg(v)
+	case f.Key == "_id":
+		return col + " < " + b.arg(v)
+	}
+
+	return "(" + col + " < " + b.arg(v) + " OR " + col + " IS NULL)"
+}
+
+func (b *sqlBuilder) equal(key string, v interface{}) string {
+	if v == nil {
+		return b.ident(key) + " IS NULL"
+	}
+
+	return b.ident(key) + " = " + b.arg(v)
+}
+
+// direction orders NULL before every other value
+func (b *sqlBuilder) direction(f SortField) string {
+	dir := " ASC"
+	if f.Direction == Descending {
+		dir = " DESC"
+	}
+
+	if b.store.Dialect != PostgresDialect || f.Key == "_id" {
+		return dir
+	}
+
+	if f.Direction == Descending {
+		return dir + " NULLS LAST"
+	}
+
+	return dir + " NULLS FIRST"
+}
+
+// sqlCursorTime turns a time that readRows wrote as an RFC 3339 string back into a time.Time
+func sqlCursorTime(s string) interface{} {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return s
+	}
+
+	return t
+}
+
+func sqlKeysetOperator(direction int) string {
+	if direction == Descending {
+		return "<"
+	}
+
+	return ">"
+}
+
+// where translates a mongodb query filter into a SQL condition
+func (b *sqlBuilder) where(filter interface{}) (string, error) {
+	if isEmptyFilter(filter) {
+		return "", nil
+	}
+
+	raw, err := bson.Marshal(filter)
+	if err != nil {
+		return "", err
+	}
+
+	return b.document(bson.Raw(raw))
+}
+
+func (b *sqlBuilder) document(d bson.Raw) (string, error) {
+	elems, err := d.Elements()
+	if err != nil {
+		return "", err
+	}
+
+	conds := make([]string, 0, len(elems))
+	for _, e := range elems {
+		var c string
+
+		switch e.Key() {
+		case "$and", "$or", "$nor":
+			c, err = b.logical(e.Key(), e.Value())
+		default:
+			c, err = b.field(e.Key(), e.Value())
+		}
+
+		if err != nil {
+			return "", err
+		}
+		conds = append(conds, c)
+	}
+
+	if len(conds) == 1 {
+		return conds[0], nil
+	}
+
+	return "(" + strings.Join(conds, " AND ") + ")", nil
+}
+
+func (b *sqlBuilder) logical(op string, v bson.RawValue) (string, error) {
+	arr, ok := v.ArrayOK()
+	if !ok {
+		return "", errors.New("pagemaster: " + op + " requires an array")
+	}
+
+	clauses, err := arr.Values()
+	if err != nil {
+		return "", err
+	}
+
+	conds := make([]string, 0, len(clauses))
+	for _, c := range clauses {
+		sub, ok := c.DocumentOK()
+		if !ok {
+			return "", errors.New("pagemaster: " + op + " requires an array of documents")
+		}
+
+		s, err := b.document(sub)
+		if err != nil {
+			return "", err
+		}
+		conds = append(conds, s)
+	}
+
+	switch op {
+	case "$and":
+		return "(" + strings.Join(conds, " AND ") + ")", nil
+	case "$or":
+		return "(" + strings.Join(conds, " OR ") + ")", nil
+	}
+
+	return "NOT (" + strings.Join(conds, " OR ") + ")", nil
+}
+
+func (b *sqlBuilder) field(key string, cond bson.RawValue) (string, error) {
+	ops, ok := cond.DocumentOK()
+	if !ok || !isOperatorDocument(ops) {
+		return b.operator(key, "$eq", cond)
+	}
+
+	elems, err := ops.Elements()
+	if err != nil {
+		return "", err
+	}
+
+	conds := make([]string, 0, len(elems))
+	for _, e := range elems {
+		c, err := b.operator(key, e.Key(), e.Value())
+		if err != nil {
+			return "", err
+		}
+		conds = append(conds, c)
+	}
+
+	if len(conds) == 1 {
+		return conds[0], nil
+	}
+
+	return "(" + strings.Join(conds, " AND ") + ")", nil
+}
+
+var sqlOperators = map[string]string{
+	"$eq":  "=",
+	"$ne":  "<>",
+	"$gt":  ">",
+	"$gte": ">=",
+	"$lt":  "<",
+	"$lte": "<=",
+}
+
+func (b *sqlBuilder) operator(key, op string, v bson.RawValue) (string, error) {
+	col := b.ident(key)
+
+	switch op {
+	case "$eq", "$ne", "$gt", "$gte", "$lt", "$lte":
+		if v.Type == bsontype.Null && op == "$eq" {
+			return col + " IS NULL", nil
+		}
+		if v.Type == bsontype.Null && op == "$ne" {
+			return col + " IS NOT NULL", nil
+		}
+		a, err := sqlArg(v)
+		if err != nil {
+			return "", err
+		}
+		return col + " " + sqlOperators[op] + " " + b.arg(a), nil
+	case "$in", "$nin":
+		arr, ok := v.ArrayOK()
+		if !ok {
+			return "", errors.New("pagemaster: " + op + " requires an array")
+		}
+		values, err := arr.Values()
+		if err != nil {
+			return "", err
+		}
+		if len(values) == 0 && op == "$in" {
+			return "1 = 0", nil
+		}
+		if len(values) == 0 {
+			return "1 = 1", nil
+		}
+		params := make([]string, 0, len(values))
+		for _, iv := range values {
+			a, err := sqlArg(iv)
+			if err != nil {
+				return "", err
+			}
+			params = append(params, b.arg(a))
+		}
+		in := " IN ("
+		if op == "$nin" {
+			in = " NOT IN ("
+		}
+		return col + in + strings.Join(params, ", ") + ")", nil
+	case "$exists":
+		if truthy(v) {
+			return col + " IS NOT NULL", nil
+		}
+		return col + " IS NULL", nil
+	}
+
+	return "", errors.New("pagemaster: unsupported filter operator " + op)
+}
+
+// sqlArg converts a BSON value into a database/sql argument
+func sqlArg(v bson.RawValue) (interface{}, error) {
+	switch v.Type {
+	case bsontype.String:
+		return v.StringValue(), nil
+	case bsontype.Int32:
+		return int64(v.Int32()), nil
+	case bsontype.Int64:
+		return v.Int64(), nil
+	case bsontype.Double:
+		return v.Double(), nil
+	case bsontype.Boolean:
+		return v.Boolean(), nil
+	case bsontype.DateTime:
+		return time.UnixMilli(v.DateTime()).UTC(), nil
+	case bsontype.Null:
+		return nil, nil
+	case bsontype.Binary:
+		_, data := v.Binary()
+		return data, nil
+	case bsontype.ObjectID:
+		return v.ObjectID().Hex(), nil
+	case bsontype.Decimal128:
+		return v.Decimal128().String(), nil
+	}
+
+	return nil, errors.New("pagemaster: cannot use a " + v.Type.String() + " value in a sql query")
+}
@@ -0,0 +1,172 @@
+package pagemaster
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type sqliteTestRow struct {
+	CreatedAt time.Time `bson:"createdAt"`
+	ID        int64     `bson:"_id"`
+}
+
+// sqliteTestStore creates an in-memory SQLite table and a MemoryStore ordering the same rows
+func sqliteTestStore(t *testing.T) (*SQLStore, *MemoryStore) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`CREATE TABLE things (id INTEGER PRIMARY KEY, name TEXT, price INTEGER NOT NULL, createdAt DATETIME NOT NULL)`)
+	if err != nil {
+		t.Fatalf("CREATE TABLE error = %v", err)
+	}
+
+	base := time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC)
+	docs := make([]interface{}, 0)
+	for i := int64(1); i <= 37; i++ {
+		var name interface{}
+		if i%4 != 0 {
+			name = string(rune('a' + i%3))
+		}
+		price := i % 5
+		createdAt := base.Add(time.Duration(i%6) * 1500 * time.Microsecond)
+
+		_, err = db.Exec(`INSERT INTO things (id, name, price, createdAt) VALUES (?, ?, ?, ?)`, i, name, price, createdAt)
+		if err != nil {
+			t.Fatalf("INSERT error = %v", err)
+		}
+		docs = append(docs, bson.D{{Key: "_id", Value: i}, {Key: "name", Value: name}, {Key: "price", Value: price}, {Key: "createdAt", Value: createdAt.UnixNano()}})
+	}
+
+	memory, err := NewMemoryStore(docs)
+	if err != nil {
+		t.Fatalf("NewMemoryStore() error = %v", err)
+	}
+
+	return NewSQLStore(db, "things", SQLiteDialect), memory
+}
+
+func TestSQLStore_FindPaginated_SQLite(t *testing.T) {
+	store, memory := sqliteTestStore(t)
+
+	tests := []struct {
+		name   string
+		filter interface{}
+		sort   []SortField
+	}{
+		0: {name: "it should page by the key column"},
+		1: {name: "it should page through ties", sort: []SortField{{Key: "price", Direction: Ascending}}},
+		2: {name: "it should page through timestamps", sort: []SortField{{Key: "createdAt", Direction: Descending}}},
+		3: {
+			name: "it should page through mixed directions and NULLs",
+			sort: []SortField{{Key: "price", Direction: Descending}, {Key: "name", Direction: Ascending}},
+		},
+		4: {name: "it should page through NULLs ascending", sort: []SortField{{Key: "name", Direction: Ascending}}},
+		5: {
+			name: "it should page through NULLs descending with row values",
+			sort: []SortField{{Key: "name", Direction: Descending}, {Key: "createdAt", Direction: Descending}},
+		},
+		6: {
+			name:   "it should page through a filtered table",
+			filter: bson.M{"price": bson.M{"$gte": 2}},
+			sort:   []SortField{{Key: "name", Direction: Descending}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs, err := memory.Find(context.TODO(), &Query{Filter: tt.filter, Sort: tt.sort})
+			if err != nil {
+				t.Fatalf("MemoryStore.Find() error = %v", err)
+			}
+			want := make([]int64, 0, len(docs))
+			for _, d := range docs {
+				want = append(want, d.Lookup("_id").Int64())
+			}
+
+			fetch := func(query string) (*PageMaster, []sqliteTestRow) {
+				r, _ := http.NewRequest("GET", "/things?pageSize=5&includeTotal=true"+query, nil)
+				p, err := New(&NewOptions{Filter: tt.filter, Request: r, Sort: tt.sort, Store: store})
+				if err != nil {
+					t.Fatalf("New() error = %v", err)
+				}
+				rows, err := Find[sqliteTestRow](p)
+				if err != nil {
+					t.Fatalf("Find() error = %v", err)
+				}
+				return p, rows
+			}
+
+			forward := make([]int64, 0)
+			p, rows := fetch("")
+			for pages := 1; ; pages++ {
+				if pages > len(want) {
+					t.Fatalf("paging forward did not end after %v pages: %v", pages, forward)
+				}
+				for _, row := range rows {
+					forward = append(forward, row.ID)
+				}
+				if !p.HasNext() {
+					break
+				}
+				p, rows = fetch("&from=" + p.NextToken())
+			}
+
+			if !reflect.DeepEqual(forward, want) {
+				t.Errorf("paging forward = %v, want %v", forward, want)
+			}
+
+			backward := make([]int64, 0)
+			last := len(rows)
+			for pages := 1; p.HasPrev(); pages++ {
+				if pages > len(want) {
+					t.Fatalf("paging backward did not end after %v pages: %v", pages, backward)
+				}
+				p, rows = fetch("&before=" + p.PrevToken())
+				page := make([]int64, 0, len(rows))
+				for _, row := range rows {
+					page = append(page, row.ID)
+				}
+				backward = append(page, backward...)
+			}
+
+			if want := want[:len(want)-last]; !reflect.DeepEqual(backward, want) {
+				t.Errorf("paging backward = %v, want %v", backward, want)
+			}
+
+			if total, _ := p.Total(); total != int64(len(want)) {
+				t.Errorf("PageMaster.Total() = %v, want %v", total, len(want))
+			}
+		})
+	}
+}
+
+func TestSQLStore_Find_SQLiteTimes(t *testing.T) {
+	store, _ := sqliteTestStore(t)
+
+	docs, err := store.Find(context.TODO(), &Query{Limit: 50, Sort: []SortField{{Key: "createdAt", Direction: Descending}}})
+	if err != nil {
+		t.Fatalf("SQLStore.Find() error = %v", err)
+	}
+
+	rows, err := decodeAll[sqliteTestRow](docs)
+	if err != nil {
+		t.Fatalf("decodeAll() error = %v", err)
+	}
+
+	base := time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC)
+	for _, row := range rows {
+		if want := base.Add(time.Duration(row.ID%6) * 1500 * time.Microsecond); !row.CreatedAt.Equal(want) {
+			t.Errorf("row %v createdAt = %v, want %v", row.ID, row.CreatedAt, want)
+		}
+	}
+}
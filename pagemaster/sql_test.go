@@ -0,0 +1,249 @@
+package pagemaster
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// testSQLDriver is a database/sql connector that records each query and answers it with canned rows
+type testSQLDriver struct {
+	args    []driver.Value
+	columns []string
+	query   string
+	rows    [][]driver.Value
+}
+
+type testSQLConn struct{ d *testSQLDriver }
+
+type testSQLStmt struct {
+	d     *testSQLDriver
+	query string
+}
+
+type testSQLRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (d *testSQLDriver) Connect(context.Context) (driver.Conn, error) { return &testSQLConn{d: d}, nil }
+func (d *testSQLDriver) Driver() driver.Driver                        { return d }
+func (d *testSQLDriver) Open(string) (driver.Conn, error)             { return &testSQLConn{d: d}, nil }
+
+func (c *testSQLConn) Prepare(query string) (driver.Stmt, error) {
+	return &testSQLStmt{d: c.d, query: query}, nil
+}
+func (c *testSQLConn) Close() error              { return nil }
+func (c *testSQLConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+func (s *testSQLStmt) Close() error  { return nil }
+func (s *testSQLStmt) NumInput() int { return -1 }
+func (s *testSQLStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+func (s *testSQLStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.query, s.d.args = s.query, args
+	return &testSQLRows{columns: s.d.columns, rows: s.d.rows}, nil
+}
+
+func (r *testSQLRows) Columns() []string { return r.columns }
+func (r *testSQLRows) Close() error      { return nil }
+func (r *testSQLRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestSQLStore_buildQuery(t *testing.T) {
+	createdAt := time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC)
+	byCreatedAt := []SortField{{Key: "createdAt", Direction: Descending}}
+	mixed := []SortField{{Key: "name", Direction: Ascending}, {Key: "createdAt", Direction: Descending}}
+
+	tests := []struct {
+		name     string
+		dialect  Dialect
+		query    *Query
+		want     string
+		wantArgs []interface{}
+		wantErr  bool
+	}{
+		0: {
+			name:    "it should order the first page by the key column",
+			dialect: PostgresDialect,
+			query:   &Query{Limit: 11},
+			want:    `SELECT * FROM things ORDER BY "id" DESC LIMIT 11`,
+		},
+		1: {
+			name:     "it should compare a single sort field directly",
+			dialect:  PostgresDialect,
+			query:    &Query{After: []interface{}{int64(42)}, Limit: 11},
+			want:     `SELECT * FROM things WHERE "id" < $1 ORDER BY "id" DESC LIMIT 11`,
+			wantArgs: []interface{}{int64(42)},
+		},
+		2: {
+			name:     "it should compare row values when directions agree",
+			dialect:  PostgresDialect,
+			query:    &Query{After: []interface{}{primitive.NewDateTimeFromTime(createdAt), int64(42)}, Filter: bson.M{"status": "active"}, Limit: 11, Sort: byCreatedAt},
+			want:     `SELECT * FROM things WHERE "status" = $1 AND (("createdAt", "id") < ($2, $3) OR "createdAt" IS NULL) ORDER BY "createdAt" DESC NULLS LAST, "id" DESC LIMIT 11`,
+			wantArgs: []interface{}{"active", createdAt, int64(42)},
+		},
+		3: {
+			name:    "it should expand the comparison when directions are mixed",
+			dialect: MySQLDialect,
+			query:   &Query{After: []interface{}{"bob", createdAt, int32(7)}, Limit: 11, Sort: mixed},
+			want: "SELECT * FROM things WHERE ((`name` > ?) OR (`name` = ? AND (`createdAt` < ? OR `createdAt` IS NULL)) OR (`name` = ? AND `createdAt` = ? AND `id` < ?)) " +
+				"ORDER BY `name` ASC, `createdAt` DESC, `id` DESC LIMIT 11",
+			wantArgs: []interface{}{"bob", "bob", createdAt, "bob", createdAt, int64(7)},
+		},
+		4: {
+			name:     "it should match NULL bounds explicitly",
+			dialect:  PostgresDialect,
+			query:    &Query{After: []interface{}{nil, int64(7)}, Limit: 11, Sort: []SortField{{Key: "deletedAt", Direction: Ascending}}},
+			want:     `SELECT * FROM things WHERE (("deletedAt" IS NOT NULL) OR ("deletedAt" IS NULL AND "id" > $1)) ORDER BY "deletedAt" ASC NULLS FIRST, "id" ASC LIMIT 11`,
+			wantArgs: []interface{}{int64(7)},
+		},
+		5: {
+			name:    "it should select the key column with the fields and skip rows",
+			dialect: SQLiteDialect,
+			query:   &Query{Fields: []string{"name", "createdAt"}, Limit: 5, Skip: 10},
+			want:    `SELECT "id", "name", "createdAt" FROM things ORDER BY "id" DESC LIMIT 5 OFFSET 10`,
+		},
+		6: {
+			name:    "it should translate filter operators",
+			dialect: SQLiteDialect,
+			query: &Query{Filter: bson.D{
+				{Key: "$or", Value: bson.A{bson.M{"tags": bson.M{"$in": bson.A{"a", "b"}}}, bson.M{"deletedAt": nil}}},
+				{Key: "price", Value: bson.D{{Key: "$gte", Value: 10}, {Key: "$lt", Value: 20.5}}},
+			}},
+			want:     `SELECT * FROM things WHERE (("tags" IN (?, ?) OR "deletedAt" IS NULL) AND ("price" >= ? AND "price" < ?)) ORDER BY "id" DESC`,
+			wantArgs: []interface{}{"a", "b", int64(10), 20.5},
+		},
+		7: {
+			name:    "it should reject unsupported filter operators",
+			dialect: PostgresDialect,
+			query:   &Query{Filter: bson.M{"name": bson.M{"$regex": "^a"}}},
+			wantErr: true,
+		},
+		8: {
+			name:     "it should bind a cursor time at full precision",
+			dialect:  SQLiteDialect,
+			query:    &Query{After: []interface{}{createdAt.Add(1500).Format(time.RFC3339Nano), int64(42)}, Limit: 11, Sort: byCreatedAt},
+			want:     `SELECT * FROM things WHERE (("createdAt", "id") < (?, ?) OR "createdAt" IS NULL) ORDER BY "createdAt" DESC, "id" DESC LIMIT 11`,
+			wantArgs: []interface{}{createdAt.Add(1500), int64(42)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSQLStore(nil, "things", tt.dialect)
+			got, args, err := s.buildQuery(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SQLStore.buildQuery() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("SQLStore.buildQuery() = %v, want %v", got, tt.want)
+			}
+			if len(args) > 0 || len(tt.wantArgs) > 0 {
+				if !reflect.DeepEqual(args, tt.wantArgs) {
+					t.Errorf("SQLStore.buildQuery() args = %#v, want %#v", args, tt.wantArgs)
+				}
+			}
+		})
+	}
+}
+
+func TestSQLStore_Find(t *testing.T) {
+	d := &testSQLDriver{
+		columns: []string{"id", "name", "avatar"},
+		rows: [][]driver.Value{
+			{int64(3), []byte("carol"), []byte{0xff, 0x00}},
+			{int64(2), []byte("bob"), nil},
+			{int64(1), []byte("alice"), nil},
+		},
+	}
+	store := NewSQLStore(sql.OpenDB(d), "users", PostgresDialect)
+
+	r, _ := http.NewRequest("GET", "/users?pageSize=2", nil)
+	p, err := New(&NewOptions{Request: r, Store: store})
+	if err != nil {
+		t.Errorf("New() error = %v", err)
+		return
+	}
+
+	first, err := p.FindPaginated()
+	if err != nil {
+		t.Errorf("PageMaster.FindPaginated() error = %v", err)
+		return
+	}
+	firstQuery := d.query
+
+	r, _ = http.NewRequest("GET", "/users?pageSize=2&from="+p.NextToken(), nil)
+	next, err := New(&NewOptions{Request: r, Store: store})
+	if err != nil {
+		t.Errorf("New() error = %v", err)
+		return
+	}
+	d.rows = [][]driver.Value{{int64(1), []byte("alice"), nil}}
+	_, err = next.FindPaginated()
+	if err != nil {
+		t.Errorf("PageMaster.FindPaginated() error = %v", err)
+		return
+	}
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		0: {
+			name: "it should return rows as documents keyed by column",
+			got:  first[0],
+			want: bson.D{{Key: "_id", Value: int64(3)}, {Key: "name", Value: "carol"}, {Key: "avatar", Value: primitive.Binary{Data: []byte{0xff, 0x00}}}},
+		},
+		1: {name: "it should fetch one row more than the page size", got: firstQuery, want: `SELECT * FROM users ORDER BY "id" DESC LIMIT 3`},
+		2: {name: "it should trim the page and have more", got: []interface{}{len(first), p.HasMore()}, want: []interface{}{2, true}},
+		3: {name: "the next page should start after the last row", got: d.query, want: `SELECT * FROM users WHERE "id" < $1 ORDER BY "id" DESC LIMIT 3`},
+		4: {name: "the next page should bind the last row's key", got: d.args, want: []driver.Value{int64(2)}},
+		5: {name: "the last page should not have more", got: next.HasMore(), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("got %#v, want %#v", tt.got, tt.want)
+			}
+		})
+	}
+
+}
+
+func TestDialect_String(t *testing.T) {
+	tests := []struct {
+		name string
+		d    Dialect
+		want string
+	}{
+		0: {name: "it should name postgres", d: PostgresDialect, want: "PostgresDialect"},
+		1: {name: "it should name sqlite", d: SQLiteDialect, want: "SQLiteDialect"},
+		2: {name: "it should not panic on an unknown dialect", d: Dialect(3), want: "Dialect(3)"},
+		3: {name: "it should not panic on a negative dialect", d: Dialect(-1), want: "Dialect(-1)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.d.String(); got != tt.want {
+				t.Errorf("Dialect.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
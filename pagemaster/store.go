@@ -0,0 +1,150 @@
+package pagemaster
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// Query describes a single page of documents requested from a Store
+type Query struct {
+	After  []interface{}
+	Fields []string
+	Filter interface{}
+	Limit  int64
+	Skip   int64
+	Sort   []SortField
+}
+
+// Store is a storage backend that a PageMaster drives to fetch pages of documents
+type Store interface {
+	Find(ctx context.Context, q *Query) ([]bson.Raw, error)
+}
+
+// Counter is implemented by stores that can count documents
+type Counter interface {
+	Count(ctx context.Context, filter interface{}) (int64, error)
+	EstimatedCount(ctx context.Context) (int64, error)
+}
+
+// MongoStore is a Store backed by a mongodb collection, passing its options through to every query
+type MongoStore struct {
+	Collation      *options.Collation
+	Collection     *mongo.Collection
+	Hint           interface{}
+	MaxTime        time.Duration
+	ReadPreference *readpref.ReadPref
+}
+
+// NewMongoStore instantiates a new MongoStore for the passed in collection
+func NewMongoStore(c *mongo.Collection) *MongoStore {
+	return &MongoStore{Collection: c}
+}
+
+// Find executes a paginated query against the mongodb collection
+func (s *MongoStore) Find(ctx context.Context, q *Query) ([]bson.Raw, error) {
+	results := make([]bson.Raw, 0)
+	sort := normalizeSort(q.Sort)
+
+	c, err := s.collection()
+	if err != nil {
+		return results, err
+	}
+
+	cursor, err := c.Find(ctx, combineFilters(q.Filter, keysetFilter(sort, q.After)), s.findOptions(q))
+
+	if err != nil {
+		return results, err
+	}
+
+	return readAll(ctx, cursor, q.Limit)
+}
+
+// findOptions merges the store's passthrough options with the pagination options for the query
+func (s *MongoStore) findOptions(q *Query) *options.FindOptions {
+	limit := q.Limit
+	skip := q.Skip
+
+	o := &options.FindOptions{
+		Collation: s.Collation,
+		Hint:      s.Hint,
+		Limit:     &limit,
+		MaxTime:   s.maxTime(),
+		Skip:      &skip,
+		Sort:      sortDocument(normalizeSort(q.Sort)),
+	}
+
+	if p := projectionDocument(q.Fields); p != nil {
+		o.Projection = p
+	}
+
+	return o
+}
+
+// collection returns the collection with the store's read preference applied
+func (s *MongoStore) collection() (*mongo.Collection, error) {
+	if s.ReadPreference == nil {
+		return s.Collection, nil
+	}
+
+	return s.Collection.Clone(options.Collection().SetReadPreference(s.ReadPreference))
+}
+
+func (s *MongoStore) maxTime() *time.Duration {
+	if s.MaxTime <= 0 {
+		return nil
+	}
+
+	t := s.MaxTime
+	return &t
+}
+
+// readAll copies every document out of a mongodb cursor and closes it
+func readAll(ctx context.Context, cursor *mongo.Cursor, size int64) ([]bson.Raw, error) {
+	if size < 0 {
+		size = 0
+	}
+
+	results := make([]bson.Raw, 0, size)
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		doc := make(bson.Raw, len(cursor.Current))
+		copy(doc, cursor.Current)
+		results = append(results, doc)
+	}
+
+	return results, cursor.Err()
+}
+
+// Count returns the number of documents in the collection matching the filter
+func (s *MongoStore) Count(ctx context.Context, filter interface{}) (int64, error) {
+	if filter == nil {
+		filter = bson.M{}
+	}
+
+	c, err := s.collection()
+	if err != nil {
+		return 0, err
+	}
+
+	return c.CountDocuments(ctx, filter, &options.CountOptions{
+		Collation: s.Collation,
+		Hint:      s.Hint,
+		MaxTime:   s.maxTime(),
+	})
+}
+
+// EstimatedCount returns the collection's document count from its metadata
+func (s *MongoStore) EstimatedCount(ctx context.Context) (int64, error) {
+	c, err := s.collection()
+	if err != nil {
+		return 0, err
+	}
+
+	return c.EstimatedDocumentCount(ctx, &options.EstimatedDocumentCountOptions{MaxTime: s.maxTime()})
+}
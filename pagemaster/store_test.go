@@ -0,0 +1,168 @@
+package pagemaster
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+func TestMongoStore_findOptions(t *testing.T) {
+	collation := &options.Collation{Locale: "en", Strength: 2}
+	limit := int64(11)
+	skip := int64(0)
+	maxTime := 2 * time.Second
+
+	tests := []struct {
+		name  string
+		store *MongoStore
+		query *Query
+		want  *options.FindOptions
+	}{
+		0: {
+			name:  "it should only set the pagination options by default",
+			store: &MongoStore{},
+			query: &Query{Limit: 11},
+			want: &options.FindOptions{
+				Limit: &limit,
+				Skip:  &skip,
+				Sort:  bson.D{{Key: "_id", Value: -1}},
+			},
+		},
+		1: {
+			name:  "it should pass through collation, hint and max time",
+			store: &MongoStore{Collation: collation, Hint: "name_1__id_1", MaxTime: maxTime},
+			query: &Query{Limit: 11, Sort: []SortField{{Key: "name", Direction: Ascending}}},
+			want: &options.FindOptions{
+				Collation: collation,
+				Hint:      "name_1__id_1",
+				Limit:     &limit,
+				MaxTime:   &maxTime,
+				Skip:      &skip,
+				Sort:      bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}},
+			},
+		},
+		2: {
+			name:  "it should project the requested fields",
+			store: &MongoStore{MaxTime: maxTime},
+			query: &Query{Fields: []string{"name"}, Limit: 11},
+			want: &options.FindOptions{
+				Limit:      &limit,
+				MaxTime:    &maxTime,
+				Projection: bson.D{{Key: "name", Value: 1}},
+				Skip:       &skip,
+				Sort:       bson.D{{Key: "_id", Value: -1}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.store.findOptions(tt.query); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MongoStore.findOptions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageMaster_Store(t *testing.T) {
+	db, _ := mongoTestInit()
+	collation := &options.Collation{Locale: "en"}
+	rp := readpref.SecondaryPreferred()
+	r, _ := http.NewRequest("GET", "/things", nil)
+
+	p, err := New(&NewOptions{
+		Collation:      collation,
+		Collection:     "testcollection",
+		Database:       db,
+		Hint:           bson.D{{Key: "_id", Value: 1}},
+		MaxTime:        time.Second,
+		ReadPreference: rp,
+		Request:        r,
+	})
+	if err != nil {
+		t.Errorf("New() error = %v", err)
+		return
+	}
+
+	got, ok := p.Store().(*MongoStore)
+	if !ok {
+		t.Errorf("PageMaster.Store() = %T, want *MongoStore", p.Store())
+		return
+	}
+
+	want := &MongoStore{Collation: collation, Collection: got.Collection, Hint: bson.D{{Key: "_id", Value: 1}}, MaxTime: time.Second, ReadPreference: rp}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PageMaster.Store() = %+v, want %+v", got, want)
+	}
+	if got.Collection.Name() != "testcollection" {
+		t.Errorf("PageMaster.Store() collection = %v, want testcollection", got.Collection.Name())
+	}
+
+	// mongo.Collection has no accessor for its read preference in this driver version
+	for _, c := range []*mongo.Collection{p.Collection(), got.Collection} {
+		if rv := reflect.ValueOf(c).Elem().FieldByName("readPreference"); rv.Pointer() != reflect.ValueOf(rp).Pointer() {
+			t.Errorf("PageMaster.Collection() read preference is not the passed in read preference")
+		}
+	}
+}
+
+func TestMongoStore_collection(t *testing.T) {
+	db, _ := mongoTestInit()
+	coll := db.Collection("testcollection")
+	rp := readpref.Nearest()
+
+	tests := []struct {
+		name  string
+		store *MongoStore
+		want  *readpref.ReadPref
+	}{
+		0: {name: "it should use the collection as is without a read preference", store: NewMongoStore(coll)},
+		1: {name: "it should apply the read preference", store: &MongoStore{Collection: coll, ReadPreference: rp}, want: rp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.store.collection()
+			if err != nil {
+				t.Fatalf("MongoStore.collection() error = %v", err)
+			}
+
+			if tt.want == nil && got != coll {
+				t.Errorf("MongoStore.collection() = %p, want %p", got, coll)
+			}
+			// mongo.Collection has no accessor for its read preference in this driver version
+			if tt.want != nil && reflect.ValueOf(got).Elem().FieldByName("readPreference").Pointer() != reflect.ValueOf(tt.want).Pointer() {
+				t.Errorf("MongoStore.collection() read preference is not %v", tt.want)
+			}
+		})
+	}
+}
+
+func TestNew_StoreOptions(t *testing.T) {
+	store, _ := NewMemoryStore(nil)
+	r, _ := http.NewRequest("GET", "/things", nil)
+
+	tests := []struct {
+		name string
+		o    *NewOptions
+	}{
+		0: {name: "it should reject a read preference", o: &NewOptions{ReadPreference: readpref.Secondary()}},
+		1: {name: "it should reject a collation", o: &NewOptions{Collation: &options.Collation{Locale: "en"}}},
+		2: {name: "it should reject a hint", o: &NewOptions{Hint: "name_1"}},
+		3: {name: "it should reject a max time", o: &NewOptions{MaxTime: time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.o.Request = r
+			tt.o.Store = store
+			if _, err := New(tt.o); !errors.Is(err, ErrStoreOptions) {
+				t.Errorf("New() error = %v, wantErr %v", err, ErrStoreOptions)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package viewer
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Paginator is the page state SendPage reads, which is implemented by *pagemaster.PageMaster
+type Paginator interface {
+	HasNext() bool
+	HasPrev() bool
+	NextToken() string
+	PageSize() *int64
+	PrevToken() string
+	Total() (int64, bool)
+}
+
+// Page is a page of results along with the paginator that fetched it
+type Page struct {
+	Data              interface{}
+	Paginator         Paginator
+	TrustProxyHeaders bool
+}
+
+// PageEnvelope describes the standard envelope format for a page of json data
+type PageEnvelope struct {
+	Data  interface{} `json:"data"`
+	Links PageLinks   `json:"links"`
+	Meta  PageMeta    `json:"meta"`
+}
+
+// PageLinks holds absolute urls to the current, next and previous pages
+type PageLinks struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+	Self string `json:"self"`
+}
+
+// PageMeta describes the page. Total is only included when the paginator counted the results
+type PageMeta struct {
+	HasMore   bool   `json:"hasMore"`
+	NextToken string `json:"nextToken,omitempty"`
+	PageSize  int64  `json:"pageSize"`
+	Total     *int64 `json:"total,omitempty"`
+}
+
+// offsetPaginator is implemented by paginators that can page by page number
+type offsetPaginator interface {
+	CurrentPage() int64
+}
+
+// SendPage sends a page of results with its links and a 200 status code
+func SendPage(w http.ResponseWriter, r *http.Request, page *Page) {
+	p := page.Paginator
+	self := requestURL(r, page.TrustProxyHeaders)
+
+	env := PageEnvelope{
+		Data:  page.Data,
+		Links: PageLinks{Self: self.String()},
+		Meta: PageMeta{
+			HasMore:   p.HasNext(),
+			NextToken: p.NextToken(),
+			PageSize:  *p.PageSize(),
+		},
+	}
+
+	if total, ok := p.Total(); ok {
+		env.Meta.Total = &total
+	}
+
+	if o, ok := p.(offsetPaginator); ok && o.CurrentPage() > 0 {
+		if p.HasNext() {
+			env.Links.Next = withQuery(self, "page", strconv.FormatInt(o.CurrentPage()+1, 10), "").String()
+		}
+		if p.HasPrev() {
+			env.Links.Prev = withQuery(self, "page", strconv.FormatInt(o.CurrentPage()-1, 10), "").String()
+		}
+	} else {
+		if p.HasNext() && p.NextToken() != "" {
+			env.Links.Next = withQuery(self, "from", p.NextToken(), "before").String()
+		}
+		if p.HasPrev() && p.PrevToken() != "" {
+			env.Links.Prev = withQuery(self, "before", p.PrevToken(), "from").String()
+		}
+	}
+
+	w.Header().Set("Link", linkHeader(env.Links))
+	SendJSON(w, env, http.StatusOK)
+}
+
+// requestURL rebuilds the absolute url of an incoming request
+func requestURL(r *http.Request, trustProxy bool) *url.URL {
+	u := *r.URL
+
+	u.Scheme = "http"
+	if r.TLS != nil {
+		u.Scheme = "https"
+	}
+	if proto := forwardedProto(r); trustProxy && proto != "" {
+		u.Scheme = proto
+	}
+
+	if u.Host == "" {
+		u.Host = r.Host
+	}
+
+	return &u
+}
+
+// forwardedProto returns the scheme in X-Forwarded-Proto when it is http or https
+func forwardedProto(r *http.Request) string {
+	proto := strings.ToLower(strings.TrimSpace(strings.Split(r.Header.Get("X-Forwarded-Proto"), ",")[0]))
+	if proto != "http" && proto != "https" {
+		return ""
+	}
+
+	return proto
+}
+
+// withQuery returns a copy of the url with the query parameter set
+func withQuery(u *url.URL, key, value, remove string) *url.URL {
+	c := *u
+	q := c.Query()
+	q.Set(key, value)
+	if remove != "" {
+		q.Del(remove)
+	}
+	c.RawQuery = q.Encode()
+
+	return &c
+}
+
+func linkHeader(l PageLinks) string {
+	links := []string{"<" + l.Self + `>; rel="self"`}
+	if l.Next != "" {
+		links = append(links, "<"+l.Next+`>; rel="next"`)
+	}
+	if l.Prev != "" {
+		links = append(links, "<"+l.Prev+`>; rel="prev"`)
+	}
+
+	return strings.Join(links, ", ")
+}
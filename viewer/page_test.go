@@ -0,0 +1,153 @@
+package viewer
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/joeyfromspace/go-api-util/v2/pagemaster"
+	"github.com/joeyfromspace/go-api-util/v2/util/testwriter"
+)
+
+var _ Paginator = (*pagemaster.PageMaster)(nil)
+
+type testPaginator struct {
+	hasNext   bool
+	hasPrev   bool
+	nextToken string
+	page      int64
+	pageSize  int64
+	prevToken string
+	total     int64
+	hasTotal  bool
+}
+
+func (p *testPaginator) CurrentPage() int64   { return p.page }
+func (p *testPaginator) HasNext() bool        { return p.hasNext }
+func (p *testPaginator) HasPrev() bool        { return p.hasPrev }
+func (p *testPaginator) NextToken() string    { return p.nextToken }
+func (p *testPaginator) PageSize() *int64     { return &p.pageSize }
+func (p *testPaginator) PrevToken() string    { return p.prevToken }
+func (p *testPaginator) Total() (int64, bool) { return p.total, p.hasTotal }
+
+func TestSendPage(t *testing.T) {
+	get := func(url string) *http.Request {
+		r, _ := http.NewRequest("GET", url, nil)
+		return r
+	}
+	behindProxy := get("/things?pageSize=2&sort=-name")
+	behindProxy.Host = "api.example.com"
+	behindProxy.Header.Set("X-Forwarded-Proto", "https")
+	untrusted := get("/things")
+	untrusted.Host = "api.example.com"
+	untrusted.Header.Set("X-Forwarded-Proto", "https")
+	spoofed := get("/things")
+	spoofed.Host = "api.example.com"
+	spoofed.Header.Set("X-Forwarded-Proto", "javascript")
+	overTLS := get("/things?page=2")
+	overTLS.Host = "api.example.com"
+	overTLS.TLS = &tls.ConnectionState{}
+	total := int64(7)
+
+	tests := []struct {
+		name      string
+		r         *http.Request
+		page      *Page
+		want      PageEnvelope
+		wantLinks string
+	}{
+		0: {
+			name: "it should link to the next page keeping the query",
+			r:    get("http://localhost:8080/things?pageSize=2&status=active"),
+			page: &Page{Data: []string{"a", "b"}, Paginator: &testPaginator{hasNext: true, nextToken: "n1", pageSize: 2}},
+			want: PageEnvelope{
+				Data: []interface{}{"a", "b"},
+				Links: PageLinks{
+					Next: "http://localhost:8080/things?from=n1&pageSize=2&status=active",
+					Self: "http://localhost:8080/things?pageSize=2&status=active",
+				},
+				Meta: PageMeta{HasMore: true, NextToken: "n1", PageSize: 2},
+			},
+			wantLinks: `<http://localhost:8080/things?pageSize=2&status=active>; rel="self", ` +
+				`<http://localhost:8080/things?from=n1&pageSize=2&status=active>; rel="next"`,
+		},
+		1: {
+			name: "it should replace the tokens of the request and honor the forwarded scheme",
+			r:    behindProxy,
+			page: &Page{Data: []string{}, Paginator: &testPaginator{hasPrev: true, prevToken: "p1", pageSize: 2, total: 7, hasTotal: true}, TrustProxyHeaders: true},
+			want: PageEnvelope{
+				Data: []interface{}{},
+				Links: PageLinks{
+					Prev: "https://api.example.com/things?before=p1&pageSize=2&sort=-name",
+					Self: "https://api.example.com/things?pageSize=2&sort=-name",
+				},
+				Meta: PageMeta{PageSize: 2, Total: &total},
+			},
+			wantLinks: `<https://api.example.com/things?pageSize=2&sort=-name>; rel="self", ` +
+				`<https://api.example.com/things?before=p1&pageSize=2&sort=-name>; rel="prev"`,
+		},
+		2: {
+			name: "it should link by page number in offset mode",
+			r:    overTLS,
+			page: &Page{Data: []string{"c"}, Paginator: &testPaginator{hasNext: true, hasPrev: true, page: 2, pageSize: 1}},
+			want: PageEnvelope{
+				Data: []interface{}{"c"},
+				Links: PageLinks{
+					Next: "https://api.example.com/things?page=3",
+					Prev: "https://api.example.com/things?page=1",
+					Self: "https://api.example.com/things?page=2",
+				},
+				Meta: PageMeta{HasMore: true, PageSize: 1},
+			},
+			wantLinks: `<https://api.example.com/things?page=2>; rel="self", ` +
+				`<https://api.example.com/things?page=3>; rel="next", ` +
+				`<https://api.example.com/things?page=1>; rel="prev"`,
+		},
+		3: {
+			name: "it should ignore the forwarded scheme unless trusted",
+			r:    untrusted,
+			page: &Page{Data: []string{}, Paginator: &testPaginator{pageSize: 2}},
+			want: PageEnvelope{
+				Data:  []interface{}{},
+				Links: PageLinks{Self: "http://api.example.com/things"},
+				Meta:  PageMeta{PageSize: 2},
+			},
+			wantLinks: `<http://api.example.com/things>; rel="self"`,
+		},
+		4: {
+			name: "it should ignore a trusted forwarded scheme that is not http or https",
+			r:    spoofed,
+			page: &Page{Data: []string{}, Paginator: &testPaginator{pageSize: 2}, TrustProxyHeaders: true},
+			want: PageEnvelope{
+				Data:  []interface{}{},
+				Links: PageLinks{Self: "http://api.example.com/things"},
+				Meta:  PageMeta{PageSize: 2},
+			},
+			wantLinks: `<http://api.example.com/things>; rel="self"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tw := testwriter.New(&testwriter.NewOptions{ExpectedStatusCode: 200})
+			SendPage(tw, tt.r, tt.page)
+
+			if got := tw.StatusCode(); got != tw.ExpectedStatusCode() {
+				t.Errorf("SendPage() status = %v, want %v", got, tw.ExpectedStatusCode())
+			}
+			if got := tw.Header().Get("Link"); got != tt.wantLinks {
+				t.Errorf("SendPage() Link = %v, want %v", got, tt.wantLinks)
+			}
+
+			var got PageEnvelope
+			if err := json.Unmarshal(tw.Body(), &got); err != nil {
+				t.Errorf("Unmarshal error: %s", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SendPage() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}